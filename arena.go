@@ -0,0 +1,64 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"reflect"
+	"unsafe"
+)
+
+// arena hands out memory from a single C allocation, so that a result
+// consisting of many values can be released with a single free.
+//
+// The size must be computed upfront. Values with pointer alignment must be
+// allocated before strings.
+type arena struct {
+	base unsafe.Pointer
+	size uintptr
+	used uintptr
+}
+
+// newArena allocates an arena of size bytes.
+func newArena(size uintptr) *arena {
+	if size == 0 {
+		size = 1
+	}
+	return &arena{
+		base: C.calloc(C.size_t(size), 1),
+		size: size,
+	}
+}
+
+// alloc returns n zeroed bytes from the arena.
+func (a *arena) alloc(n uintptr) unsafe.Pointer {
+	if a.used+n > a.size {
+		panic("arena too small")
+	}
+	p := unsafe.Pointer(uintptr(a.base) + a.used)
+	a.used += n
+	return p
+}
+
+// cstring copies s with a terminating zero into the arena.
+func (a *arena) cstring(s string) *C.char {
+	p := a.alloc(cstringSize(s))
+
+	var buf []byte
+	*(*reflect.SliceHeader)(unsafe.Pointer(&buf)) = reflect.SliceHeader{
+		Data: uintptr(p),
+		Len:  len(s) + 1,
+		Cap:  len(s) + 1,
+	}
+	copy(buf, s)
+	buf[len(s)] = 0
+
+	return (*C.char)(p)
+}
+
+// cstringSize returns the arena size needed for s.
+func cstringSize(s string) uintptr {
+	return uintptr(len(s) + 1)
+}
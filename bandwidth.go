@@ -0,0 +1,271 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// BandwidthLimiter limits the upload and download rate of all projects using it.
+type BandwidthLimiter struct {
+	upload   *rateLimiter
+	download *rateLimiter
+}
+
+// newBandwidthLimiter creates a limiter, non-positive rates are unlimited.
+func newBandwidthLimiter(uploadBytesPerSecond, downloadBytesPerSecond int64) *BandwidthLimiter {
+	return &BandwidthLimiter{
+		upload:   newRateLimiter(uploadBytesPerSecond),
+		download: newRateLimiter(downloadBytesPerSecond),
+	}
+}
+
+//export uplink_new_bandwidth_limiter
+// uplink_new_bandwidth_limiter creates a bandwidth limiter, which can be shared
+// by projects through Uplink_Config.
+//
+// When a rate is 0 or negative, the direction is not limited.
+func uplink_new_bandwidth_limiter(upload_bytes_per_second, download_bytes_per_second C.int64_t) *C.Uplink_BandwidthLimiter { //nolint:golint
+	limiter := newBandwidthLimiter(int64(upload_bytes_per_second), int64(download_bytes_per_second))
+	return (*C.Uplink_BandwidthLimiter)(mallocHandle(universe.Add(limiter)))
+}
+
+//export uplink_bandwidth_limiter_set_limits
+// uplink_bandwidth_limiter_set_limits changes the rates of the limiter, which
+// applies immediately to all running transfers using it.
+//
+// When a rate is 0 or negative, the direction is not limited.
+func uplink_bandwidth_limiter_set_limits(limiter *C.Uplink_BandwidthLimiter, upload_bytes_per_second, download_bytes_per_second C.int64_t) *C.Uplink_Error { //nolint:golint
+	if limiter == nil {
+		return mallocError(ErrNull.New("limiter"))
+	}
+
+	lim, ok := universe.Get(limiter._handle).(*BandwidthLimiter)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("limiter"))
+	}
+
+	lim.upload.SetRate(int64(upload_bytes_per_second))
+	lim.download.SetRate(int64(download_bytes_per_second))
+	return nil
+}
+
+//export uplink_project_set_bandwidth_limits
+// uplink_project_set_bandwidth_limits changes the rates of the project's limiter,
+// which applies immediately to all running transfers using it. When the project was
+// opened with a limiter from Uplink_Config, the change affects all projects sharing it.
+//
+// When a rate is 0 or negative, the direction is not limited.
+func uplink_project_set_bandwidth_limits(project *C.Uplink_Project, upload_bytes_per_second, download_bytes_per_second C.int64_t) *C.Uplink_Error { //nolint:golint
+	if project == nil {
+		return mallocError(ErrNull.New("project"))
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("project"))
+	}
+
+	proj.bandwidth.upload.SetRate(int64(upload_bytes_per_second))
+	proj.bandwidth.download.SetRate(int64(download_bytes_per_second))
+	return nil
+}
+
+//export uplink_free_bandwidth_limiter
+// uplink_free_bandwidth_limiter frees the limiter. Projects opened with it keep using it.
+func uplink_free_bandwidth_limiter(limiter *C.Uplink_BandwidthLimiter) {
+	if limiter == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(limiter))
+	defer universe.Del(limiter._handle)
+}
+
+// configBandwidthLimiter returns the limiter of config or a new unlimited one when not set.
+func configBandwidthLimiter(limiter *C.Uplink_BandwidthLimiter) (*BandwidthLimiter, error) {
+	if limiter == nil {
+		return newBandwidthLimiter(0, 0), nil
+	}
+
+	lim, ok := universe.Get(limiter._handle).(*BandwidthLimiter)
+	if !ok {
+		return nil, ErrInvalidHandle.New("bandwidth_limiter")
+	}
+	return lim, nil
+}
+
+// transferLimiter returns the limiter to use for a single transfer.
+//
+// A positive bytesPerSecond overrides the shared limiter, a negative one disables
+// limiting and zero uses the shared limiter.
+func transferLimiter(shared *rateLimiter, bytesPerSecond C.int64_t) *rateLimiter {
+	switch {
+	case bytesPerSecond > 0:
+		return newRateLimiter(int64(bytesPerSecond))
+	case bytesPerSecond < 0:
+		return nil
+	default:
+		return shared
+	}
+}
+
+// rateLimiter is a token bucket, which holds at most one second of tokens.
+//
+// All methods are safe to call on a nil rateLimiter, which does not limit.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter for bytesPerSecond, non-positive is unlimited.
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	limiter := &rateLimiter{}
+	limiter.SetRate(bytesPerSecond)
+	return limiter
+}
+
+// SetRate changes the rate, non-positive is unlimited.
+func (limiter *rateLimiter) SetRate(bytesPerSecond int64) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := time.Now()
+	limiter.refill(now)
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	limiter.rate = bytesPerSecond
+	limiter.last = now
+	if limiter.tokens > float64(bytesPerSecond) {
+		limiter.tokens = float64(bytesPerSecond)
+	}
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (limiter *rateLimiter) refill(now time.Time) {
+	if limiter.rate <= 0 {
+		return
+	}
+	limiter.tokens += now.Sub(limiter.last).Seconds() * float64(limiter.rate)
+	if limiter.tokens > float64(limiter.rate) {
+		limiter.tokens = float64(limiter.rate)
+	}
+	limiter.last = now
+}
+
+// Chunk returns how many of n bytes can be transferred with a single Wait.
+func (limiter *rateLimiter) Chunk(n int) int {
+	if limiter == nil {
+		return n
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if limiter.rate > 0 && int64(n) > limiter.rate {
+		return int(limiter.rate)
+	}
+	return n
+}
+
+// Wait blocks until n bytes can be transferred or ctx is canceled.
+func (limiter *rateLimiter) Wait(ctx context.Context, n int) error {
+	if limiter == nil || n <= 0 {
+		return nil
+	}
+
+	for {
+		limiter.mu.Lock()
+		if limiter.rate <= 0 {
+			limiter.mu.Unlock()
+			return nil
+		}
+
+		limiter.refill(time.Now())
+		need := float64(n)
+		if need > float64(limiter.rate) {
+			// the rate was lowered after the chunk was chosen.
+			need = float64(limiter.rate)
+		}
+		if limiter.tokens >= need {
+			limiter.tokens -= need
+			limiter.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((need - limiter.tokens) / float64(limiter.rate) * float64(time.Second))
+		limiter.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// throttledUpload limits the rate of writes to an upload.
+type throttledUpload struct {
+	objectUpload
+	ctx     context.Context
+	limiter *rateLimiter
+}
+
+// throttleUpload wraps upload when limiter is not nil.
+func throttleUpload(ctx context.Context, upload objectUpload, limiter *rateLimiter) objectUpload {
+	if limiter == nil {
+		return upload
+	}
+	return &throttledUpload{objectUpload: upload, ctx: ctx, limiter: limiter}
+}
+
+// Write writes p in chunks allowed by the limiter.
+func (upload *throttledUpload) Write(p []byte) (written int, err error) {
+	for len(p) > 0 {
+		chunk := upload.limiter.Chunk(len(p))
+		if err := upload.limiter.Wait(upload.ctx, chunk); err != nil {
+			return written, err
+		}
+
+		n, err := upload.objectUpload.Write(p[:chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[chunk:]
+	}
+	return written, nil
+}
+
+// throttledDownload limits the rate of reads from a download.
+type throttledDownload struct {
+	objectDownload
+	ctx     context.Context
+	limiter *rateLimiter
+}
+
+// throttleDownload wraps download when limiter is not nil.
+func throttleDownload(ctx context.Context, download objectDownload, limiter *rateLimiter) objectDownload {
+	if limiter == nil {
+		return download
+	}
+	return &throttledDownload{objectDownload: download, ctx: ctx, limiter: limiter}
+}
+
+// Read reads at most a chunk allowed by the limiter and waits for it.
+func (download *throttledDownload) Read(p []byte) (int, error) {
+	n, err := download.objectDownload.Read(p[:download.limiter.Chunk(len(p))])
+	if werr := download.limiter.Wait(download.ctx, n); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	var unlimited *rateLimiter
+	require.Equal(t, 1<<20, unlimited.Chunk(1<<20))
+	require.NoError(t, unlimited.Wait(ctx, 1<<20))
+
+	limiter := newRateLimiter(1000)
+	require.Equal(t, 1000, limiter.Chunk(5000))
+	require.Equal(t, 10, limiter.Chunk(10))
+
+	start := time.Now()
+	require.NoError(t, limiter.Wait(ctx, 100))
+	require.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	limiter.SetRate(0)
+	start = time.Now()
+	require.NoError(t, limiter.Wait(ctx, 1<<20))
+	require.True(t, time.Since(start) < time.Second)
+
+	limiter.SetRate(1)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.True(t, errors.Is(limiter.Wait(canceled, 1), context.Canceled))
+}
@@ -0,0 +1,280 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"storj.io/uplink"
+)
+
+// defaultStatConcurrency is the number of concurrent stats in uplink_stat_objects.
+const defaultStatConcurrency = 8
+
+//export uplink_delete_objects
+// uplink_delete_objects deletes the objects at keys with concurrent deletes.
+//
+// The results are in the same order as keys, each with the error of deleting the key
+// or NULL on success. The error of the result is only set when no delete was attempted.
+func uplink_delete_objects(project *C.Uplink_Project, bucket_name *C.char, keys **C.char, count C.size_t, options *C.Uplink_DeleteObjectsOptions) C.Uplink_DeleteObjectsResult { //nolint:golint
+	return uplink_delete_objects_with_cancel(project, bucket_name, keys, count, options, nil)
+}
+
+//export uplink_delete_objects_with_cancel
+// uplink_delete_objects_with_cancel deletes the objects at keys with concurrent deletes.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_delete_objects_with_cancel(project *C.Uplink_Project, bucket_name *C.char, keys **C.char, count C.size_t, options *C.Uplink_DeleteObjectsOptions, token *C.Uplink_CancelToken) C.Uplink_DeleteObjectsResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_DeleteObjectsResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_DeleteObjectsResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+
+	goKeys, err := goStrings(keys, count, "keys")
+	if err != nil {
+		return C.Uplink_DeleteObjectsResult{
+			error: mallocError(err),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_DeleteObjectsResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_DeleteObjectsResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	concurrency := defaultDeleteConcurrency
+	if options != nil && options.concurrency > 0 {
+		concurrency = int(options.concurrency)
+	}
+
+	if len(goKeys) == 0 {
+		return C.Uplink_DeleteObjectsResult{}
+	}
+
+	bucket := C.GoString(bucket_name)
+	deleteErrs := make([]error, len(goKeys))
+	runConcurrently(len(goKeys), concurrency, func(i int) {
+		_, deleteErrs[i] = deleteKeyWithParts(scope.ctx, proj.Project, bucket, goKeys[i])
+	})
+
+	results := (*C.Uplink_DeleteObjectsEntry)(C.calloc(C.size_t(len(goKeys)), C.sizeof_Uplink_DeleteObjectsEntry))
+
+	var array []C.Uplink_DeleteObjectsEntry
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(results)),
+		Len:  len(goKeys),
+		Cap:  len(goKeys),
+	}
+
+	for i, key := range goKeys {
+		array[i] = C.Uplink_DeleteObjectsEntry{
+			key:   C.CString(key),
+			error: mallocError(deleteErrs[i]),
+		}
+	}
+
+	return C.Uplink_DeleteObjectsResult{
+		results: results,
+		count:   C.size_t(len(goKeys)),
+	}
+}
+
+//export uplink_free_delete_objects_result
+// uplink_free_delete_objects_result frees the results and any associated resources.
+func uplink_free_delete_objects_result(result C.Uplink_DeleteObjectsResult) {
+	uplink_free_error(result.error)
+	if result.results == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(result.results))
+
+	var array []C.Uplink_DeleteObjectsEntry
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(result.results)),
+		Len:  int(result.count),
+		Cap:  int(result.count),
+	}
+
+	for _, entry := range array {
+		C.free(unsafe.Pointer(entry.key))
+		uplink_free_error(entry.error)
+	}
+}
+
+//export uplink_stat_objects
+// uplink_stat_objects returns information about the objects at keys, fetched concurrently.
+//
+// The results are in the same order as keys, each with either the object or the error.
+// The error of the result is only set when no stat was attempted.
+func uplink_stat_objects(project *C.Uplink_Project, bucket_name *C.char, keys **C.char, count C.size_t) C.Uplink_StatObjectsResult { //nolint:golint
+	return uplink_stat_objects_with_cancel(project, bucket_name, keys, count, nil)
+}
+
+//export uplink_stat_objects_with_cancel
+// uplink_stat_objects_with_cancel returns information about the objects at keys, fetched concurrently.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_stat_objects_with_cancel(project *C.Uplink_Project, bucket_name *C.char, keys **C.char, count C.size_t, token *C.Uplink_CancelToken) C.Uplink_StatObjectsResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_StatObjectsResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_StatObjectsResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+
+	goKeys, err := goStrings(keys, count, "keys")
+	if err != nil {
+		return C.Uplink_StatObjectsResult{
+			error: mallocError(err),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_StatObjectsResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_StatObjectsResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	if len(goKeys) == 0 {
+		return C.Uplink_StatObjectsResult{}
+	}
+
+	bucket := C.GoString(bucket_name)
+	objects := make([]*uplink.Object, len(goKeys))
+	statErrs := make([]error, len(goKeys))
+	runConcurrently(len(goKeys), defaultStatConcurrency, func(i int) {
+		objects[i], statErrs[i] = statObject(scope.ctx, proj.Project, bucket, goKeys[i])
+	})
+
+	results := (*C.Uplink_ObjectResult)(C.calloc(C.size_t(len(goKeys)), C.sizeof_Uplink_ObjectResult))
+
+	var array []C.Uplink_ObjectResult
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(results)),
+		Len:  len(goKeys),
+		Cap:  len(goKeys),
+	}
+
+	for i := range goKeys {
+		array[i] = C.Uplink_ObjectResult{
+			object: mallocObject(objects[i]),
+			error:  mallocError(statErrs[i]),
+		}
+	}
+
+	return C.Uplink_StatObjectsResult{
+		results: results,
+		count:   C.size_t(len(goKeys)),
+	}
+}
+
+//export uplink_free_stat_objects_result
+// uplink_free_stat_objects_result frees the results and any associated resources.
+func uplink_free_stat_objects_result(result C.Uplink_StatObjectsResult) {
+	uplink_free_error(result.error)
+	if result.results == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(result.results))
+
+	var array []C.Uplink_ObjectResult
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(result.results)),
+		Len:  int(result.count),
+		Cap:  int(result.count),
+	}
+
+	for _, entry := range array {
+		uplink_free_object_result(entry)
+	}
+}
+
+// goStrings converts a C array of count strings, none of which may be NULL.
+func goStrings(strs **C.char, count C.size_t, name string) ([]string, error) {
+	if count == 0 {
+		return nil, nil
+	}
+	if strs == nil {
+		return nil, ErrNull.New("%s", name)
+	}
+
+	n, ok := safeConvertToInt(count)
+	if !ok {
+		return nil, ErrInvalidArg.New("%s count too large", name)
+	}
+
+	var array []*C.char
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(strs)),
+		Len:  n,
+		Cap:  n,
+	}
+
+	result := make([]string, n)
+	for i, s := range array {
+		if s == nil {
+			return nil, ErrNull.New("%s[%d]", name, i)
+		}
+		result[i] = C.GoString(s)
+	}
+	return result, nil
+}
+
+// runConcurrently calls fn for every index in [0, n) using at most concurrency goroutines.
+func runConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency > n {
+		concurrency = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
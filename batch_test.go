@@ -0,0 +1,35 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConcurrently(t *testing.T) {
+	var running, maxRunning int32
+	visited := make([]int32, 100)
+
+	runConcurrently(len(visited), 4, func(i int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		atomic.AddInt32(&visited[i], 1)
+		atomic.AddInt32(&running, -1)
+	})
+
+	for _, v := range visited {
+		require.Equal(t, int32(1), v)
+	}
+	require.LessOrEqual(t, maxRunning, int32(4))
+
+	runConcurrently(0, 4, func(i int) { t.Fatal("unexpected call") })
+}
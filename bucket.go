@@ -6,6 +6,7 @@ package main
 // #include "uplink_definitions.h"
 import "C"
 import (
+	"fmt"
 	"unsafe"
 
 	"storj.io/uplink"
@@ -14,6 +15,14 @@ import (
 //export uplink_stat_bucket
 // uplink_stat_bucket returns information about a bucket.
 func uplink_stat_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Uplink_BucketResult { //nolint:golint
+	return uplink_stat_bucket_with_cancel(project, bucket_name, nil)
+}
+
+//export uplink_stat_bucket_with_cancel
+// uplink_stat_bucket_with_cancel returns information about a bucket.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_stat_bucket_with_cancel(project *C.Uplink_Project, bucket_name *C.char, token *C.Uplink_CancelToken) C.Uplink_BucketResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_BucketResult{
 			error: mallocError(ErrNull.New("project")),
@@ -32,7 +41,15 @@ func uplink_stat_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Uplink
 		}
 	}
 
-	bucket, err := proj.StatBucket(proj.scope.ctx, C.GoString(bucket_name))
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	bucket, err := proj.StatBucket(scope.ctx, C.GoString(bucket_name))
 
 	return C.Uplink_BucketResult{
 		error:  mallocError(err),
@@ -45,6 +62,14 @@ func uplink_stat_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Uplink
 //
 // When bucket already exists it returns a valid Bucket and ErrBucketExists.
 func uplink_create_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Uplink_BucketResult { //nolint:golint
+	return uplink_create_bucket_with_cancel(project, bucket_name, nil)
+}
+
+//export uplink_create_bucket_with_cancel
+// uplink_create_bucket_with_cancel creates a new bucket.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_create_bucket_with_cancel(project *C.Uplink_Project, bucket_name *C.char, token *C.Uplink_CancelToken) C.Uplink_BucketResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_BucketResult{
 			error: mallocError(ErrNull.New("project")),
@@ -63,7 +88,15 @@ func uplink_create_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Upli
 		}
 	}
 
-	bucket, err := proj.CreateBucket(proj.scope.ctx, C.GoString(bucket_name))
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	bucket, err := proj.CreateBucket(scope.ctx, C.GoString(bucket_name))
 
 	return C.Uplink_BucketResult{
 		error:  mallocError(err),
@@ -76,6 +109,14 @@ func uplink_create_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Upli
 //
 // When bucket already exists it returns a valid Bucket and ErrBucketExists.
 func uplink_ensure_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Uplink_BucketResult { //nolint:golint
+	return uplink_ensure_bucket_with_cancel(project, bucket_name, nil)
+}
+
+//export uplink_ensure_bucket_with_cancel
+// uplink_ensure_bucket_with_cancel creates a new bucket and ignores the error when it already exists.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_ensure_bucket_with_cancel(project *C.Uplink_Project, bucket_name *C.char, token *C.Uplink_CancelToken) C.Uplink_BucketResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_BucketResult{
 			error: mallocError(ErrNull.New("project")),
@@ -94,7 +135,15 @@ func uplink_ensure_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Upli
 		}
 	}
 
-	bucket, err := proj.EnsureBucket(proj.scope.ctx, C.GoString(bucket_name))
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	bucket, err := proj.EnsureBucket(scope.ctx, C.GoString(bucket_name))
 
 	return C.Uplink_BucketResult{
 		error:  mallocError(err),
@@ -107,6 +156,14 @@ func uplink_ensure_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Upli
 //
 // When bucket is not empty it returns ErrBucketNotEmpty.
 func uplink_delete_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Uplink_BucketResult { //nolint:golint
+	return uplink_delete_bucket_with_cancel(project, bucket_name, nil)
+}
+
+//export uplink_delete_bucket_with_cancel
+// uplink_delete_bucket_with_cancel deletes a bucket.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_delete_bucket_with_cancel(project *C.Uplink_Project, bucket_name *C.char, token *C.Uplink_CancelToken) C.Uplink_BucketResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_BucketResult{
 			error: mallocError(ErrNull.New("project")),
@@ -125,14 +182,106 @@ func uplink_delete_bucket(project *C.Uplink_Project, bucket_name *C.char) C.Upli
 		}
 	}
 
-	deleted, err := proj.DeleteBucket(proj.scope.ctx, C.GoString(bucket_name))
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	deleted, err := proj.DeleteBucket(scope.ctx, C.GoString(bucket_name))
 	return C.Uplink_BucketResult{
 		error:  mallocError(err),
 		bucket: mallocBucket(deleted),
 	}
 }
 
+//export uplink_delete_bucket_with_options
+// uplink_delete_bucket_with_options deletes a bucket.
+//
+// With options force, all objects in the bucket are deleted first. Failed object deletes
+// are returned in the failures list and the bucket is then not deleted.
+func uplink_delete_bucket_with_options(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_DeleteBucketOptions) C.Uplink_DeleteBucketResult { //nolint:golint
+	return uplink_delete_bucket_with_options_with_cancel(project, bucket_name, options, nil)
+}
+
+//export uplink_delete_bucket_with_options_with_cancel
+// uplink_delete_bucket_with_options_with_cancel deletes a bucket.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_delete_bucket_with_options_with_cancel(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_DeleteBucketOptions, token *C.Uplink_CancelToken) C.Uplink_DeleteBucketResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_DeleteBucketResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_DeleteBucketResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_DeleteBucketResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_DeleteBucketResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	name := C.GoString(bucket_name)
+
+	var deletedObjects int64
+	var failures []deleteFailure
+	if options != nil && bool(options.force) {
+		concurrency := defaultDeleteConcurrency
+		if options.concurrency > 0 {
+			concurrency = int(options.concurrency)
+		}
+
+		deletedObjects, failures, err = deletePrefix(scope.ctx, proj.Project, name, "", concurrency)
+		if err == nil && len(failures) > 0 {
+			err = fmt.Errorf("%w: %d objects could not be deleted", uplink.ErrBucketNotEmpty, len(failures))
+		}
+	}
+
+	var deleted *uplink.Bucket
+	if err == nil {
+		deleted, err = proj.DeleteBucket(scope.ctx, name)
+	}
+
+	cfailures, count := mallocDeleteFailures(failures)
+	return C.Uplink_DeleteBucketResult{
+		bucket:          mallocBucket(deleted),
+		deleted_objects: C.int64_t(deletedObjects),
+		failures:        cfailures,
+		failures_count:  count,
+		error:           mallocError(err),
+	}
+}
+
+//export uplink_free_delete_bucket_result
+// uplink_free_delete_bucket_result frees any resources associated with delete bucket result.
+func uplink_free_delete_bucket_result(result C.Uplink_DeleteBucketResult) {
+	uplink_free_error(result.error)
+	uplink_free_bucket(result.bucket)
+	freeDeleteFailures(result.failures, result.failures_count)
+}
+
 func mallocBucket(bucket *uplink.Bucket) *C.Uplink_Bucket {
+	return mallocBucketWithStats(bucket, nil)
+}
+
+// mallocBucketWithStats converts bucket, populating the statistics when stats is not nil.
+func mallocBucketWithStats(bucket *uplink.Bucket, stats *bucketStats) *C.Uplink_Bucket {
 	if bucket == nil {
 		return nil
 	}
@@ -140,6 +289,11 @@ func mallocBucket(bucket *uplink.Bucket) *C.Uplink_Bucket {
 	cbucket := (*C.Uplink_Bucket)(C.calloc(C.sizeof_Uplink_Bucket, 1))
 	cbucket.name = C.CString(bucket.Name)
 	cbucket.created = timeToUnix(bucket.Created)
+	if stats != nil {
+		cbucket.has_stats = C.bool(true)
+		cbucket.object_count = C.int64_t(stats.objects)
+		cbucket.total_bytes = C.int64_t(stats.bytes)
+	}
 
 	return cbucket
 }
@@ -6,22 +6,43 @@ package main
 // #include "uplink_definitions.h"
 import "C"
 import (
+	"context"
+	"path"
+	"reflect"
+	"strings"
+	"time"
 	"unsafe"
 
 	"storj.io/uplink"
 )
 
+// bucketIterator is implemented by uplink.BucketIterator and the iterators wrapping it.
+type bucketIterator interface {
+	Next() bool
+	Item() *uplink.Bucket
+	Err() error
+}
+
 // BucketIterator is an iterator over buckets.
 type BucketIterator struct {
 	scope
-	iterator *uplink.BucketIterator
+	iterator bucketIterator
 
 	initialError error
+	async        asyncQueue
 }
 
 //export uplink_list_buckets
 // uplink_list_buckets lists buckets.
 func uplink_list_buckets(project *C.Uplink_Project, options *C.Uplink_ListBucketsOptions) *C.Uplink_BucketIterator {
+	return uplink_list_buckets_with_cancel(project, options, nil)
+}
+
+//export uplink_list_buckets_with_cancel
+// uplink_list_buckets_with_cancel lists buckets.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_list_buckets_with_cancel(project *C.Uplink_Project, options *C.Uplink_ListBucketsOptions, token *C.Uplink_CancelToken) *C.Uplink_BucketIterator {
 	if project == nil {
 		return (*C.Uplink_BucketIterator)(mallocHandle(universe.Add(&BucketIterator{
 			initialError: ErrNull.New("project"),
@@ -34,19 +55,72 @@ func uplink_list_buckets(project *C.Uplink_Project, options *C.Uplink_ListBucket
 		})))
 	}
 
-	opts := &uplink.ListBucketsOptions{}
-	if options != nil {
-		opts.Cursor = C.GoString(options.cursor)
+	filter, err := listBucketsFilter(options)
+	if err != nil {
+		return (*C.Uplink_BucketIterator)(mallocHandle(universe.Add(&BucketIterator{
+			initialError: err,
+		})))
 	}
 
-	scope := proj.scope.child()
-	iterator := proj.ListBuckets(scope.ctx, opts)
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return (*C.Uplink_BucketIterator)(mallocHandle(universe.Add(&BucketIterator{
+			initialError: err,
+		})))
+	}
+	iterator := proj.listBuckets(scope.ctx, options, filter)
 	return (*C.Uplink_BucketIterator)(mallocHandle(universe.Add(&BucketIterator{
 		scope:    scope,
 		iterator: iterator,
 	})))
 }
 
+//export uplink_list_buckets_async
+// uplink_list_buckets_async starts listing buckets.
+//
+// on_bucket is invoked for every listed bucket and takes ownership of it. When
+// on_bucket returns false the listing stops. on_done is invoked once the listing
+// finishes, fails or stops. The listing is canceled when the project is closed.
+// When the listing cannot be started, an error is returned and no callback is invoked.
+func uplink_list_buckets_async(project *C.Uplink_Project, options *C.Uplink_ListBucketsOptions, on_bucket C.Uplink_BucketCallback, on_done C.Uplink_ErrorCallback, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if project == nil {
+		return mallocError(ErrNull.New("project"))
+	}
+	if on_bucket == nil {
+		return mallocError(ErrNull.New("on_bucket"))
+	}
+	if on_done == nil {
+		return mallocError(ErrNull.New("on_done"))
+	}
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("project"))
+	}
+
+	filter, err := listBucketsFilter(options)
+	if err != nil {
+		return mallocError(err)
+	}
+
+	scope := proj.scope.child()
+	iterator := proj.listBuckets(scope.ctx, options, filter)
+
+	go func() {
+		defer scope.cancel()
+
+		for iterator.Next() {
+			if !callBucketCallback(on_bucket, mallocBucketItem(iterator), user_data) {
+				callErrorCallback(on_done, nil, user_data)
+				return
+			}
+		}
+
+		callErrorCallback(on_done, iterator.Err(), user_data)
+	}()
+
+	return nil
+}
+
 //export uplink_bucket_iterator_next
 // uplink_bucket_iterator_next prepares next Bucket for reading.
 //
@@ -67,6 +141,41 @@ func uplink_bucket_iterator_next(iterator *C.Uplink_BucketIterator) C.bool {
 	return C.bool(iter.iterator.Next())
 }
 
+//export uplink_bucket_iterator_next_queued
+// uplink_bucket_iterator_next_queued starts preparing the next Bucket for reading.
+//
+// Once it finishes a completion with user_data is added to the queue, where has_next
+// reports the same value as uplink_bucket_iterator_next. When it cannot be started,
+// an error is returned and no completion is added.
+func uplink_bucket_iterator_next_queued(iterator *C.Uplink_BucketIterator, queue *C.Uplink_CompletionQueue, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if iterator == nil {
+		return mallocError(ErrNull.New("iterator"))
+	}
+	if queue == nil {
+		return mallocError(ErrNull.New("queue"))
+	}
+
+	iter, ok := universe.Get(iterator._handle).(*BucketIterator)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("iterator"))
+	}
+	completions, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("queue"))
+	}
+
+	iter.async.Go(func() {
+		hasNext := iter.initialError == nil && iter.iterator.Next()
+		completions.push(completion{
+			kind:     C.UPLINK_COMPLETION_NEXT,
+			userData: user_data,
+			hasNext:  hasNext,
+		})
+	})
+
+	return nil
+}
+
 //export uplink_bucket_iterator_err
 // uplink_bucket_iterator_err returns error, if one happened during iteration.
 func uplink_bucket_iterator_err(iterator *C.Uplink_BucketIterator) *C.Uplink_Error {
@@ -97,7 +206,7 @@ func uplink_bucket_iterator_item(iterator *C.Uplink_BucketIterator) *C.Uplink_Bu
 		return nil
 	}
 
-	return mallocBucket(iter.iterator.Item())
+	return mallocBucketItem(iter.iterator)
 }
 
 //export uplink_free_bucket_iterator
@@ -116,3 +225,281 @@ func uplink_free_bucket_iterator(iterator *C.Uplink_BucketIterator) {
 		}
 	}
 }
+
+//export uplink_list_buckets_page
+// uplink_list_buckets_page lists up to page_size buckets into a single allocation.
+//
+// To continue the listing, pass the returned cursor in options. When more is false
+// the listing is complete. The result must be freed with uplink_free_bucket_page_result.
+func uplink_list_buckets_page(project *C.Uplink_Project, options *C.Uplink_ListBucketsOptions, page_size C.size_t) C.Uplink_BucketPageResult { //nolint:golint
+	return uplink_list_buckets_page_with_cancel(project, options, page_size, nil)
+}
+
+//export uplink_list_buckets_page_with_cancel
+// uplink_list_buckets_page_with_cancel lists up to page_size buckets into a single allocation.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_list_buckets_page_with_cancel(project *C.Uplink_Project, options *C.Uplink_ListBucketsOptions, page_size C.size_t, token *C.Uplink_CancelToken) C.Uplink_BucketPageResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_BucketPageResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+
+	pageSize, ok := safeConvertToInt(page_size)
+	if !ok || pageSize <= 0 {
+		return C.Uplink_BucketPageResult{
+			error: mallocError(ErrInvalidArg.New("page_size")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_BucketPageResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	filter, err := listBucketsFilter(options)
+	if err != nil {
+		return C.Uplink_BucketPageResult{
+			error: mallocError(err),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_BucketPageResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	// the statistics are only computed for the buckets in the page.
+	iterator := proj.listFilteredBuckets(scope.ctx, options, filter)
+
+	var buckets []*uplink.Bucket
+	more := false
+	for iterator.Next() {
+		if len(buckets) == pageSize {
+			more = true
+			break
+		}
+		buckets = append(buckets, iterator.Item())
+	}
+	if err := iterator.Err(); err != nil {
+		return C.Uplink_BucketPageResult{
+			error: mallocError(err),
+		}
+	}
+
+	var stats []*bucketStats
+	if options != nil && options.stats != nil {
+		stats, err = proj.bucketsStatistics(scope.ctx, buckets, bucketStatsOptionsFrom(options.stats))
+		if err != nil {
+			return C.Uplink_BucketPageResult{
+				error: mallocError(err),
+			}
+		}
+	}
+
+	cursor := ""
+	if options != nil {
+		cursor = C.GoString(options.cursor)
+	}
+	if len(buckets) > 0 {
+		cursor = buckets[len(buckets)-1].Name
+	}
+
+	return bucketPageToC(buckets, stats, cursor, more)
+}
+
+//export uplink_free_bucket_page_result
+// uplink_free_bucket_page_result frees the page and all buckets in it.
+func uplink_free_bucket_page_result(result C.Uplink_BucketPageResult) {
+	uplink_free_error(result.error)
+	C.free(unsafe.Pointer(result.buckets))
+}
+
+// bucketPageToC copies the buckets, their statistics when stats is not nil, and the cursor
+// into a single allocation.
+func bucketPageToC(buckets []*uplink.Bucket, stats []*bucketStats, cursor string, more bool) C.Uplink_BucketPageResult {
+	size := uintptr(len(buckets)) * C.sizeof_Uplink_Bucket
+	for _, bucket := range buckets {
+		size += cstringSize(bucket.Name)
+	}
+	size += cstringSize(cursor)
+
+	mem := newArena(size)
+
+	var array []C.Uplink_Bucket
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(mem.alloc(uintptr(len(buckets)) * C.sizeof_Uplink_Bucket)),
+		Len:  len(buckets),
+		Cap:  len(buckets),
+	}
+
+	for i, bucket := range buckets {
+		array[i] = C.Uplink_Bucket{
+			name:    mem.cstring(bucket.Name),
+			created: timeToUnix(bucket.Created),
+		}
+		if stats != nil {
+			array[i].has_stats = C.bool(true)
+			array[i].object_count = C.int64_t(stats[i].objects)
+			array[i].total_bytes = C.int64_t(stats[i].bytes)
+		}
+	}
+
+	return C.Uplink_BucketPageResult{
+		buckets: (*C.Uplink_Bucket)(mem.base),
+		count:   C.size_t(len(buckets)),
+		cursor:  mem.cstring(cursor),
+		more:    C.bool(more),
+	}
+}
+
+func listBucketsOptions(options *C.Uplink_ListBucketsOptions) *uplink.ListBucketsOptions {
+	opts := &uplink.ListBucketsOptions{}
+	if options != nil {
+		opts.Cursor = C.GoString(options.cursor)
+
+		// buckets are listed ordered by name, skip the ones before prefix.
+		if prefix := C.GoString(options.prefix); prefix != "" && opts.Cursor < prefix {
+			opts.Cursor = cursorBefore(prefix)
+		}
+	}
+	return opts
+}
+
+// cursorBefore returns a cursor, which lists prefix and the names after it.
+func cursorBefore(prefix string) string {
+	last := prefix[len(prefix)-1]
+	if last == 0 {
+		return prefix[:len(prefix)-1]
+	}
+	return prefix[:len(prefix)-1] + string([]byte{last - 1})
+}
+
+// listBuckets lists the buckets of the project matching filter, computing their statistics when requested.
+func (proj *Project) listBuckets(ctx context.Context, options *C.Uplink_ListBucketsOptions, filter *bucketFilter) bucketIterator {
+	iterator := proj.listFilteredBuckets(ctx, options, filter)
+	if options != nil && options.stats != nil {
+		iterator = newBucketsWithStats(ctx, proj, iterator, bucketStatsOptionsFrom(options.stats))
+	}
+	return iterator
+}
+
+// listFilteredBuckets lists the buckets of the project matching filter.
+func (proj *Project) listFilteredBuckets(ctx context.Context, options *C.Uplink_ListBucketsOptions, filter *bucketFilter) bucketIterator {
+	var iterator bucketIterator = proj.ListBuckets(ctx, listBucketsOptions(options))
+	if filter != nil {
+		iterator = filter.apply(iterator)
+	}
+	return iterator
+}
+
+// mallocBucketItem converts the current bucket of iterator, including its statistics.
+func mallocBucketItem(iterator bucketIterator) *C.Uplink_Bucket {
+	if withStats, ok := iterator.(*bucketsWithStats); ok {
+		return mallocBucketWithStats(withStats.Item(), withStats.Stats())
+	}
+	return mallocBucket(iterator.Item())
+}
+
+// bucketFilter selects listed buckets on the client side.
+//
+// A nil bucketFilter matches all buckets.
+type bucketFilter struct {
+	prefix        string
+	glob          string
+	createdAfter  time.Time
+	createdBefore time.Time
+}
+
+// listBucketsFilter creates the filter for options, nil when no filter is set.
+func listBucketsFilter(options *C.Uplink_ListBucketsOptions) (*bucketFilter, error) {
+	if options == nil {
+		return nil, nil
+	}
+
+	filter := &bucketFilter{
+		prefix: C.GoString(options.prefix),
+		glob:   C.GoString(options.glob),
+	}
+	if options.created_after > 0 {
+		filter.createdAfter = time.Unix(int64(options.created_after), 0)
+	}
+	if options.created_before > 0 {
+		filter.createdBefore = time.Unix(int64(options.created_before), 0)
+	}
+
+	if filter.glob != "" {
+		if _, err := path.Match(filter.glob, ""); err != nil {
+			return nil, ErrInvalidArg.New("glob: %v", err)
+		}
+	}
+
+	if *filter == (bucketFilter{}) {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Match returns whether bucket passes the filter.
+func (filter *bucketFilter) Match(bucket *uplink.Bucket) bool {
+	if filter == nil {
+		return true
+	}
+
+	if !strings.HasPrefix(bucket.Name, filter.prefix) {
+		return false
+	}
+	if filter.glob != "" {
+		if ok, _ := path.Match(filter.glob, bucket.Name); !ok {
+			return false
+		}
+	}
+	if !filter.createdAfter.IsZero() && !bucket.Created.After(filter.createdAfter) {
+		return false
+	}
+	if !filter.createdBefore.IsZero() && !bucket.Created.Before(filter.createdBefore) {
+		return false
+	}
+	return true
+}
+
+// pastPrefix returns whether bucket and all buckets listed after it are past the prefix.
+func (filter *bucketFilter) pastPrefix(bucket *uplink.Bucket) bool {
+	return filter.prefix != "" && bucket.Name > filter.prefix && !strings.HasPrefix(bucket.Name, filter.prefix)
+}
+
+// apply wraps iterator to skip the buckets not matching the filter.
+func (filter *bucketFilter) apply(iterator bucketIterator) *filteredBuckets {
+	return &filteredBuckets{bucketIterator: iterator, filter: filter}
+}
+
+// filteredBuckets is a bucket iterator, which skips buckets not matching the filter.
+type filteredBuckets struct {
+	bucketIterator
+	filter *bucketFilter
+	done   bool
+}
+
+// Next prepares the next matching bucket for reading.
+func (buckets *filteredBuckets) Next() bool {
+	if buckets.done {
+		return false
+	}
+	for buckets.bucketIterator.Next() {
+		bucket := buckets.bucketIterator.Item()
+		if buckets.filter.pastPrefix(bucket) {
+			buckets.done = true
+			return false
+		}
+		if buckets.filter.Match(bucket) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+// sliceBuckets iterates over buckets in memory.
+type sliceBuckets struct {
+	buckets []*uplink.Bucket
+	next    int
+}
+
+func (buckets *sliceBuckets) Next() bool {
+	if buckets.next >= len(buckets.buckets) {
+		return false
+	}
+	buckets.next++
+	return true
+}
+
+func (buckets *sliceBuckets) Item() *uplink.Bucket { return buckets.buckets[buckets.next-1] }
+func (buckets *sliceBuckets) Err() error           { return nil }
+
+func TestBucketFilter(t *testing.T) {
+	now := time.Now()
+	bucket := &uplink.Bucket{Name: "tenant-42-prod", Created: now}
+
+	var all *bucketFilter
+	require.True(t, all.Match(bucket))
+
+	for _, tt := range []struct {
+		filter bucketFilter
+		match  bool
+	}{
+		{filter: bucketFilter{prefix: "tenant-42-"}, match: true},
+		{filter: bucketFilter{prefix: "tenant-43-"}},
+		{filter: bucketFilter{glob: "tenant-*-prod"}, match: true},
+		{filter: bucketFilter{glob: "tenant-*-dev"}},
+		{filter: bucketFilter{createdAfter: now.Add(-time.Minute), createdBefore: now.Add(time.Minute)}, match: true},
+		{filter: bucketFilter{createdAfter: now}},
+		{filter: bucketFilter{createdBefore: now}},
+	} {
+		filter := tt.filter
+		require.Equal(t, tt.match, filter.Match(bucket), "%+v", filter)
+	}
+}
+
+func TestFilteredBucketsStopAfterPrefix(t *testing.T) {
+	source := &sliceBuckets{buckets: []*uplink.Bucket{
+		{Name: "tenant-1-dev"},
+		{Name: "tenant-1-prod"},
+		{Name: "tenant-2-prod"},
+		{Name: "tenant-3-prod"},
+	}}
+
+	filter := &bucketFilter{prefix: "tenant-1-", glob: "*-prod"}
+	iterator := filter.apply(source)
+
+	var names []string
+	for iterator.Next() {
+		names = append(names, iterator.Item().Name)
+	}
+	require.NoError(t, iterator.Err())
+	require.Equal(t, []string{"tenant-1-prod"}, names)
+	require.Equal(t, 3, source.next, "listing continued past the prefix")
+	require.False(t, iterator.Next())
+}
+
+func TestCursorBefore(t *testing.T) {
+	// the listing starts after the cursor, so it must be before every name with the prefix.
+	cursor := cursorBefore("tenant-1")
+	require.True(t, cursor < "tenant-1")
+	require.True(t, cursor >= "tenant-0")
+}
+
+func TestBucketPageToC(t *testing.T) {
+	buckets := []*uplink.Bucket{
+		{Name: "alpha", Created: time.Unix(100, 0)},
+		{Name: "beta", Created: time.Unix(200, 0)},
+	}
+	stats := []*bucketStats{{objects: 1, bytes: 10}, {objects: 2, bytes: 20}}
+
+	page := bucketPageToC(buckets, stats, "beta", true)
+	defer uplink_free_bucket_page_result(page)
+
+	require.EqualValues(t, 2, page.count)
+	require.True(t, bool(page.more))
+	require.Equal(t, "beta", cString(unsafe.Pointer(page.cursor)))
+
+	first := page.buckets
+	require.Equal(t, "alpha", cString(unsafe.Pointer(first.name)))
+	require.EqualValues(t, 100, first.created)
+	require.True(t, bool(first.has_stats))
+	require.EqualValues(t, 1, first.object_count)
+
+	second := first
+	*(*unsafe.Pointer)(unsafe.Pointer(&second)) = unsafe.Pointer(uintptr(unsafe.Pointer(first)) + unsafe.Sizeof(*first))
+	require.Equal(t, "beta", cString(unsafe.Pointer(second.name)))
+	require.EqualValues(t, 20, second.total_bytes)
+
+	plain := bucketPageToC(buckets, nil, "", false)
+	defer uplink_free_bucket_page_result(plain)
+	require.False(t, bool(plain.buckets.has_stats))
+}
@@ -0,0 +1,273 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"sync"
+	"time"
+
+	"storj.io/uplink"
+)
+
+const (
+	// defaultBucketStatsConcurrency is the number of buckets walked concurrently for statistics.
+	defaultBucketStatsConcurrency = 4
+	// defaultBucketStatsTTL is how long bucket statistics are reused by default.
+	defaultBucketStatsTTL = time.Minute
+)
+
+//export uplink_stat_bucket_with_stats
+// uplink_stat_bucket_with_stats returns information about a bucket together with
+// the number of objects and bytes stored in it.
+//
+// Counting walks the whole bucket, the result is reused for repeated calls within
+// the ttl of options. When options is NULL, the defaults are used.
+func uplink_stat_bucket_with_stats(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_BucketStatsOptions) C.Uplink_BucketResult { //nolint:golint
+	return uplink_stat_bucket_with_stats_with_cancel(project, bucket_name, options, nil)
+}
+
+//export uplink_stat_bucket_with_stats_with_cancel
+// uplink_stat_bucket_with_stats_with_cancel returns information about a bucket together with
+// the number of objects and bytes stored in it.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_stat_bucket_with_stats_with_cancel(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_BucketStatsOptions, token *C.Uplink_CancelToken) C.Uplink_BucketResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_BucketResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	bucket, err := proj.StatBucket(scope.ctx, C.GoString(bucket_name))
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+
+	stats, err := proj.bucketStatistics(scope.ctx, bucket, bucketStatsOptionsFrom(options))
+	if err != nil {
+		return C.Uplink_BucketResult{
+			error: mallocError(err),
+		}
+	}
+
+	return C.Uplink_BucketResult{
+		bucket: mallocBucketWithStats(bucket, &stats),
+	}
+}
+
+// bucketStats is the number of objects and bytes in a bucket.
+type bucketStats struct {
+	objects int64
+	bytes   int64
+}
+
+// bucketStatsOptions configures how bucket statistics are computed.
+type bucketStatsOptions struct {
+	concurrency int
+	ttl         time.Duration
+}
+
+// bucketStatsOptionsFrom converts options, using the defaults when options is NULL.
+func bucketStatsOptionsFrom(options *C.Uplink_BucketStatsOptions) bucketStatsOptions {
+	opts := bucketStatsOptions{
+		concurrency: defaultBucketStatsConcurrency,
+		ttl:         defaultBucketStatsTTL,
+	}
+	if options == nil {
+		return opts
+	}
+
+	if options.concurrency > 0 {
+		opts.concurrency = int(options.concurrency)
+	}
+	if options.ttl_milliseconds != 0 {
+		opts.ttl = time.Duration(options.ttl_milliseconds) * time.Millisecond
+	}
+	return opts
+}
+
+// bucketStatsCache keeps recently computed bucket statistics.
+//
+// The zero value is ready to use.
+type bucketStatsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedBucketStats
+}
+
+// cachedBucketStats are the statistics of a bucket created at created.
+type cachedBucketStats struct {
+	stats    bucketStats
+	created  time.Time
+	computed time.Time
+}
+
+// get returns the statistics of bucket when they were computed within ttl before now.
+func (cache *bucketStatsCache) get(bucket *uplink.Bucket, ttl time.Duration, now time.Time) (bucketStats, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[bucket.Name]
+	if !ok || !entry.created.Equal(bucket.Created) || now.Sub(entry.computed) >= ttl {
+		return bucketStats{}, false
+	}
+	return entry.stats, true
+}
+
+// put stores the statistics of bucket computed at now.
+func (cache *bucketStatsCache) put(bucket *uplink.Bucket, stats bucketStats, now time.Time) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.entries == nil {
+		cache.entries = map[string]cachedBucketStats{}
+	}
+	cache.entries[bucket.Name] = cachedBucketStats{
+		stats:    stats,
+		created:  bucket.Created,
+		computed: now,
+	}
+}
+
+// bucketStatistics counts the objects and bytes in bucket, unless they are cached.
+func (proj *Project) bucketStatistics(ctx context.Context, bucket *uplink.Bucket, options bucketStatsOptions) (bucketStats, error) {
+	if options.ttl > 0 {
+		if stats, ok := proj.bucketStats.get(bucket, options.ttl, time.Now()); ok {
+			return stats, nil
+		}
+	}
+
+	s := newSummarizer("", 0)
+	if err := s.walk(ctx, proj.Project, bucket.Name, nil, 0); err != nil {
+		return bucketStats{}, err
+	}
+
+	stats := bucketStats{objects: s.total.objects, bytes: s.total.bytes}
+	if options.ttl > 0 {
+		proj.bucketStats.put(bucket, stats, time.Now())
+	}
+	return stats, nil
+}
+
+// bucketsStatistics computes the statistics of buckets, walking up to options.concurrency
+// buckets at the same time.
+func (proj *Project) bucketsStatistics(ctx context.Context, buckets []*uplink.Bucket, options bucketStatsOptions) ([]*bucketStats, error) {
+	stats := make([]*bucketStats, len(buckets))
+	statsErrs := make([]error, len(buckets))
+	runConcurrently(len(buckets), options.concurrency, func(i int) {
+		var bucket bucketStats
+		bucket, statsErrs[i] = proj.bucketStatistics(ctx, buckets[i], options)
+		stats[i] = &bucket
+	})
+
+	for _, err := range statsErrs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// bucketsWithStats computes the statistics of the listed buckets, walking
+// up to options.concurrency buckets at the same time.
+type bucketsWithStats struct {
+	ctx      context.Context
+	project  *Project
+	iterator bucketIterator
+	options  bucketStatsOptions
+
+	pending      []*uplink.Bucket
+	pendingStats []*bucketStats
+	item         *uplink.Bucket
+	itemStats    bucketStats
+	err          error
+}
+
+func newBucketsWithStats(ctx context.Context, project *Project, iterator bucketIterator, options bucketStatsOptions) *bucketsWithStats {
+	return &bucketsWithStats{
+		ctx:      ctx,
+		project:  project,
+		iterator: iterator,
+		options:  options,
+	}
+}
+
+// Next prepares the next bucket and its statistics.
+func (buckets *bucketsWithStats) Next() bool {
+	if len(buckets.pending) == 0 && !buckets.fill() {
+		buckets.item = nil
+		return false
+	}
+
+	buckets.item, buckets.itemStats = buckets.pending[0], *buckets.pendingStats[0]
+	buckets.pending, buckets.pendingStats = buckets.pending[1:], buckets.pendingStats[1:]
+	return true
+}
+
+// fill reads the next batch of buckets and computes their statistics.
+func (buckets *bucketsWithStats) fill() bool {
+	if buckets.err != nil {
+		return false
+	}
+
+	for len(buckets.pending) < buckets.options.concurrency && buckets.iterator.Next() {
+		buckets.pending = append(buckets.pending, buckets.iterator.Item())
+	}
+	if len(buckets.pending) == 0 {
+		return false
+	}
+
+	stats, err := buckets.project.bucketsStatistics(buckets.ctx, buckets.pending, buckets.options)
+	if err != nil {
+		buckets.err = err
+		buckets.pending = nil
+		return false
+	}
+
+	buckets.pendingStats = stats
+	return true
+}
+
+// Item returns the current bucket.
+func (buckets *bucketsWithStats) Item() *uplink.Bucket { return buckets.item }
+
+// Stats returns the statistics of the current bucket.
+func (buckets *bucketsWithStats) Stats() *bucketStats {
+	if buckets.item == nil {
+		return nil
+	}
+	return &buckets.itemStats
+}
+
+// Err returns the error of the listing or of computing the statistics.
+func (buckets *bucketsWithStats) Err() error {
+	if buckets.err != nil {
+		return buckets.err
+	}
+	return buckets.iterator.Err()
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestBucketStatsCache(t *testing.T) {
+	now := time.Now()
+	bucket := &uplink.Bucket{Name: "alpha", Created: now.Add(-time.Hour)}
+
+	var cache bucketStatsCache
+	_, ok := cache.get(bucket, time.Minute, now)
+	require.False(t, ok)
+
+	cache.put(bucket, bucketStats{objects: 3, bytes: 42}, now)
+
+	stats, ok := cache.get(bucket, time.Minute, now.Add(30*time.Second))
+	require.True(t, ok)
+	require.Equal(t, bucketStats{objects: 3, bytes: 42}, stats)
+
+	_, ok = cache.get(bucket, time.Minute, now.Add(time.Minute))
+	require.False(t, ok)
+
+	recreated := &uplink.Bucket{Name: "alpha", Created: now}
+	_, ok = cache.get(recreated, time.Minute, now)
+	require.False(t, ok)
+}
@@ -0,0 +1,94 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+/*
+#include "uplink_definitions.h"
+
+static inline void uplink_internal_call_read_callback(Uplink_ReadCallback callback, Uplink_ReadResult result, void *user_data) {
+    callback(result, user_data);
+}
+
+static inline void uplink_internal_call_write_callback(Uplink_WriteCallback callback, Uplink_WriteResult result, void *user_data) {
+    callback(result, user_data);
+}
+
+static inline void uplink_internal_call_error_callback(Uplink_ErrorCallback callback, Uplink_Error *error, void *user_data) {
+    callback(error, user_data);
+}
+
+static inline void uplink_internal_call_progress_callback(Uplink_ProgressCallback callback, int64_t bytes_transferred, int64_t total_bytes, int64_t elapsed_milliseconds, void *user_data) {
+    callback(bytes_transferred, total_bytes, elapsed_milliseconds, user_data);
+}
+
+static inline void uplink_internal_call_summary_progress_callback(Uplink_SummaryProgressCallback callback, int64_t objects, int64_t bytes, void *user_data) {
+    callback(objects, bytes, user_data);
+}
+
+static inline bool uplink_internal_call_object_callback(Uplink_ObjectCallback callback, Uplink_Object *object, void *user_data) {
+    return callback(object, user_data);
+}
+
+static inline bool uplink_internal_call_bucket_callback(Uplink_BucketCallback callback, Uplink_Bucket *bucket, void *user_data) {
+    return callback(bucket, user_data);
+}
+*/
+import "C"
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// asyncQueue runs asynchronous operations of a single handle one at a time,
+// in the order they were started.
+type asyncQueue struct {
+	mu   sync.Mutex
+	last chan struct{}
+}
+
+// Go runs fn in a new goroutine after all previously started operations have finished.
+func (queue *asyncQueue) Go(fn func()) {
+	queue.mu.Lock()
+	prev := queue.last
+	done := make(chan struct{})
+	queue.last = done
+	queue.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+		fn()
+	}()
+}
+
+func callReadCallback(callback C.Uplink_ReadCallback, result C.Uplink_ReadResult, userData unsafe.Pointer) {
+	C.uplink_internal_call_read_callback(callback, result, userData)
+}
+
+func callWriteCallback(callback C.Uplink_WriteCallback, result C.Uplink_WriteResult, userData unsafe.Pointer) {
+	C.uplink_internal_call_write_callback(callback, result, userData)
+}
+
+func callErrorCallback(callback C.Uplink_ErrorCallback, err error, userData unsafe.Pointer) {
+	C.uplink_internal_call_error_callback(callback, mallocError(err), userData)
+}
+
+func callProgressCallback(callback C.Uplink_ProgressCallback, transferred, total int64, elapsed time.Duration, userData unsafe.Pointer) {
+	C.uplink_internal_call_progress_callback(callback, C.int64_t(transferred), C.int64_t(total), C.int64_t(elapsed/time.Millisecond), userData)
+}
+
+func callSummaryProgressCallback(callback C.Uplink_SummaryProgressCallback, objects, bytes int64, userData unsafe.Pointer) {
+	C.uplink_internal_call_summary_progress_callback(callback, C.int64_t(objects), C.int64_t(bytes), userData)
+}
+
+func callObjectCallback(callback C.Uplink_ObjectCallback, object *C.Uplink_Object, userData unsafe.Pointer) bool {
+	return bool(C.uplink_internal_call_object_callback(callback, object, userData))
+}
+
+func callBucketCallback(callback C.Uplink_BucketCallback, bucket *C.Uplink_Bucket, userData unsafe.Pointer) bool {
+	return bool(C.uplink_internal_call_bucket_callback(callback, bucket, userData))
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncQueue_Order(t *testing.T) {
+	var queue asyncQueue
+	var wg sync.WaitGroup
+
+	var mu sync.Mutex
+	var order []int
+	for i := 0; i < 100; i++ {
+		i := i
+		wg.Add(1)
+		queue.Go(func() {
+			defer wg.Done()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	require.Len(t, order, 100)
+	for i, v := range order {
+		require.Equal(t, i, v)
+	}
+}
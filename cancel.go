@@ -0,0 +1,84 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"time"
+	"unsafe"
+)
+
+// CancelToken allows to cancel individual calls from a different thread.
+type CancelToken struct {
+	scope
+}
+
+//export uplink_new_cancel_token
+// uplink_new_cancel_token creates a new cancellation token.
+//
+// When timeout_milliseconds is positive the token is automatically
+// canceled once the timeout elapses.
+func uplink_new_cancel_token(timeout_milliseconds C.int64_t) *C.Uplink_CancelToken { //nolint:golint
+	var ctx context.Context
+	var cancel func()
+	if timeout_milliseconds > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), time.Duration(timeout_milliseconds)*time.Millisecond)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	return (*C.Uplink_CancelToken)(mallocHandle(universe.Add(&CancelToken{scope{ctx, cancel}})))
+}
+
+//export uplink_cancel_token_cancel
+// uplink_cancel_token_cancel cancels all calls that are using the token.
+//
+// It is safe to call from a different thread than the one running the calls.
+func uplink_cancel_token_cancel(token *C.Uplink_CancelToken) *C.Uplink_Error {
+	if token == nil {
+		return mallocError(ErrNull.New("token"))
+	}
+
+	tok, ok := universe.Get(token._handle).(*CancelToken)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("token"))
+	}
+
+	tok.cancel()
+	return nil
+}
+
+//export uplink_free_cancel_token
+// uplink_free_cancel_token cancels the token and frees any associated resources.
+func uplink_free_cancel_token(token *C.Uplink_CancelToken) {
+	if token == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(token))
+	defer universe.Del(token._handle)
+
+	tok, ok := universe.Get(token._handle).(*CancelToken)
+	if ok {
+		tok.cancel()
+	}
+}
+
+// childWithCancel creates an inherited scope, which is additionally canceled
+// when the token is canceled or its deadline passes.
+//
+// When token is nil, it behaves the same as child.
+func (parent *scope) childWithCancel(token *C.Uplink_CancelToken) (scope, error) {
+	if token == nil {
+		return parent.child(), nil
+	}
+
+	tok, ok := universe.Get(token._handle).(*CancelToken)
+	if !ok {
+		return scope{}, ErrInvalidHandle.New("token")
+	}
+
+	return parent.childWithContext(tok.ctx), nil
+}
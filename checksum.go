@@ -0,0 +1,260 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // used for content digests, not for security.
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"sync"
+
+	"storj.io/uplink"
+)
+
+// checksumMetadataPrefix is the prefix of the reserved custom metadata keys,
+// which hold the hex encoded content checksums of an object.
+const checksumMetadataPrefix = "uplink-c:checksum:"
+
+// checksumAlgorithms lists the supported algorithms with their option flags.
+var checksumAlgorithms = []struct {
+	flag C.uint32_t
+	name string
+	new  func() hash.Hash
+}{
+	{C.UPLINK_CHECKSUM_SHA256, "sha256", sha256.New},
+	{C.UPLINK_CHECKSUM_MD5, "md5", md5.New},
+	{C.UPLINK_CHECKSUM_CRC32C, "crc32c", func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+}
+
+// checksums computes content digests with several algorithms at once.
+type checksums struct {
+	names  []string
+	hashes []hash.Hash
+}
+
+// newChecksums returns the checksums enabled by flags, nil when there are none.
+func newChecksums(flags C.uint32_t) *checksums {
+	var sums checksums
+	for _, algorithm := range checksumAlgorithms {
+		if flags&algorithm.flag != 0 {
+			sums.names = append(sums.names, algorithm.name)
+			sums.hashes = append(sums.hashes, algorithm.new())
+		}
+	}
+	if len(sums.hashes) == 0 {
+		return nil
+	}
+	return &sums
+}
+
+// checksumsFor returns the checksums for the algorithms stored in custom metadata,
+// nil when there are none.
+func checksumsFor(custom uplink.CustomMetadata) *checksums {
+	var sums checksums
+	for _, algorithm := range checksumAlgorithms {
+		if _, ok := custom[checksumMetadataPrefix+algorithm.name]; ok {
+			sums.names = append(sums.names, algorithm.name)
+			sums.hashes = append(sums.hashes, algorithm.new())
+		}
+	}
+	if len(sums.hashes) == 0 {
+		return nil
+	}
+	return &sums
+}
+
+// newResumedChecksumUpload wraps upload, which continues resumed, when flags enable any
+// checksum. The parts uploaded before the upload was interrupted are downloaded again
+// to include them in the checksums.
+func newResumedChecksumUpload(ctx context.Context, upload objectUpload, resumed *chunkedUpload, flags C.uint32_t) (objectUpload, error) {
+	sums := newChecksums(flags)
+	if sums == nil {
+		return upload, nil
+	}
+
+	for _, part := range resumed.parts {
+		download, err := resumed.project.DownloadObject(ctx, resumed.bucket, part.Key, nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(sums, download)
+		_ = download.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &checksumUpload{
+		objectUpload: upload,
+		ctx:          ctx,
+		sums:         sums,
+	}, nil
+}
+
+// verifyChecksums checks data, which is the complete uncompressed content of object,
+// against the checksums stored with object.
+func verifyChecksums(object *uplink.Object, data []byte) error {
+	sums := checksumsFor(object.Custom)
+	if sums == nil {
+		return nil
+	}
+	_, _ = sums.Write(data)
+	return sums.Verify(object.Custom)
+}
+
+// Write adds p to all digests.
+func (sums *checksums) Write(p []byte) (int, error) {
+	for _, h := range sums.hashes {
+		_, _ = h.Write(p)
+	}
+	return len(p), nil
+}
+
+// Metadata returns the current digests as custom metadata.
+func (sums *checksums) Metadata() uplink.CustomMetadata {
+	custom := uplink.CustomMetadata{}
+	for i, h := range sums.hashes {
+		custom[checksumMetadataPrefix+sums.names[i]] = hex.EncodeToString(h.Sum(nil))
+	}
+	return custom
+}
+
+// Verify compares the current digests with the ones in custom metadata.
+func (sums *checksums) Verify(custom uplink.CustomMetadata) error {
+	for name, actual := range sums.Metadata() {
+		if expected := custom[name]; expected != actual {
+			return ErrChecksumMismatch.New("%s: expected %s, got %s", name[len(checksumMetadataPrefix):], expected, actual)
+		}
+	}
+	return nil
+}
+
+// withChecksums returns custom extended with the digests of sums.
+func withChecksums(custom uplink.CustomMetadata, sums *checksums) uplink.CustomMetadata {
+	merged := uplink.CustomMetadata{}
+	for k, v := range custom {
+		merged[k] = v
+	}
+	for k, v := range sums.Metadata() {
+		merged[k] = v
+	}
+	return merged
+}
+
+// checksumUpload computes checksums of the written data and stores them
+// in the custom metadata on commit.
+type checksumUpload struct {
+	objectUpload
+	ctx context.Context
+
+	mu     sync.Mutex
+	sums   *checksums
+	custom uplink.CustomMetadata
+}
+
+// newChecksumUpload wraps upload when flags enable any checksum.
+func newChecksumUpload(ctx context.Context, upload objectUpload, flags C.uint32_t) objectUpload {
+	sums := newChecksums(flags)
+	if sums == nil {
+		return upload
+	}
+	return &checksumUpload{
+		objectUpload: upload,
+		ctx:          ctx,
+		sums:         sums,
+	}
+}
+
+// Write uploads p and adds the written bytes to the checksums.
+func (upload *checksumUpload) Write(p []byte) (int, error) {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	n, err := upload.objectUpload.Write(p)
+	upload.sums.Write(p[:n])
+	return n, err
+}
+
+// SetCustomMetadata sets custom metadata, which is extended with the checksums on commit.
+func (upload *checksumUpload) SetCustomMetadata(ctx context.Context, custom uplink.CustomMetadata) error {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	if err := upload.objectUpload.SetCustomMetadata(ctx, custom); err != nil {
+		return err
+	}
+	upload.custom = custom
+	return nil
+}
+
+// Commit stores the checksums in the custom metadata and commits the upload.
+func (upload *checksumUpload) Commit() error {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	if err := upload.objectUpload.SetCustomMetadata(upload.ctx, withChecksums(upload.custom, upload.sums)); err != nil {
+		return err
+	}
+	return upload.objectUpload.Commit()
+}
+
+// Info returns the last information about the uploaded object including the
+// checksums of the data written so far.
+func (upload *checksumUpload) Info() *uplink.Object {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	info := upload.objectUpload.Info()
+	if info == nil {
+		return nil
+	}
+	object := *info
+	object.Custom = withChecksums(info.Custom, upload.sums)
+	return &object
+}
+
+// checksumDownload verifies the checksums of a full download once it reaches the end.
+type checksumDownload struct {
+	objectDownload
+	sums     *checksums
+	expected uplink.CustomMetadata
+}
+
+// newChecksumDownload wraps download when it reads the whole object and the
+// object has checksums.
+func newChecksumDownload(download objectDownload, opts *uplink.DownloadOptions) objectDownload {
+	info := download.Info()
+	if opts != nil && (opts.Offset != 0 || (opts.Length >= 0 && opts.Length < info.System.ContentLength)) {
+		return download
+	}
+
+	sums := checksumsFor(info.Custom)
+	if sums == nil {
+		return download
+	}
+	return &checksumDownload{
+		objectDownload: download,
+		sums:           sums,
+		expected:       info.Custom,
+	}
+}
+
+// Read reads from the download and verifies the checksums at the end.
+func (download *checksumDownload) Read(p []byte) (int, error) {
+	n, err := download.objectDownload.Read(p)
+	download.sums.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if verr := download.sums.Verify(download.expected); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+type memoryDownload struct {
+	io.Reader
+	object *uplink.Object
+}
+
+func (download *memoryDownload) Close() error         { return nil }
+func (download *memoryDownload) Info() *uplink.Object { return download.object }
+
+func TestChecksums(t *testing.T) {
+	sums := checksumsFor(uplink.CustomMetadata{
+		checksumMetadataPrefix + "sha256": "",
+		checksumMetadataPrefix + "md5":    "",
+		checksumMetadataPrefix + "crc32c": "",
+		"other":                           "",
+	})
+	require.NotNil(t, sums)
+	sums.Write([]byte("hello world"))
+
+	custom := sums.Metadata()
+	require.Equal(t, uplink.CustomMetadata{
+		checksumMetadataPrefix + "sha256": "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		checksumMetadataPrefix + "md5":    "5eb63bbbe01eeed093cb22bb8f5acdc3",
+		checksumMetadataPrefix + "crc32c": "c99465aa",
+	}, custom)
+	require.NoError(t, sums.Verify(custom))
+
+	custom[checksumMetadataPrefix+"md5"] = "00"
+	require.True(t, ErrChecksumMismatch.Has(sums.Verify(custom)))
+
+	require.Nil(t, checksumsFor(uplink.CustomMetadata{"other": ""}))
+}
+
+func TestChecksumDownload(t *testing.T) {
+	data := []byte("hello world")
+	sums := checksumsFor(uplink.CustomMetadata{checksumMetadataPrefix + "sha256": ""})
+	sums.Write(data)
+
+	object := &uplink.Object{Custom: sums.Metadata()}
+	object.System.ContentLength = int64(len(data))
+
+	full := &uplink.DownloadOptions{Offset: 0, Length: -1}
+
+	download := newChecksumDownload(&memoryDownload{bytes.NewReader(data), object}, full)
+	read, err := ioutil.ReadAll(download)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+
+	download = newChecksumDownload(&memoryDownload{bytes.NewReader([]byte("hello World")), object}, full)
+	_, err = ioutil.ReadAll(download)
+	require.True(t, ErrChecksumMismatch.Has(err))
+
+	// partial downloads are not verified.
+	partial := &uplink.DownloadOptions{Offset: 0, Length: 5}
+	download = newChecksumDownload(&memoryDownload{bytes.NewReader([]byte("hello")), object}, partial)
+	_, err = ioutil.ReadAll(download)
+	require.NoError(t, err)
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	data := []byte("hello world")
+	sums := checksumsFor(uplink.CustomMetadata{checksumMetadataPrefix + "crc32c": ""})
+	_, _ = sums.Write(data)
+
+	object := &uplink.Object{Custom: sums.Metadata()}
+	require.NoError(t, verifyChecksums(object, data))
+	require.True(t, ErrChecksumMismatch.Has(verifyChecksums(object, []byte("hello World"))))
+
+	require.NoError(t, verifyChecksums(&uplink.Object{}, data))
+}
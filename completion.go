@@ -0,0 +1,179 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// CompletionQueue collects finished asynchronous operations, which are
+// drained by polling, and signals their availability through a file descriptor.
+type CompletionQueue struct {
+	mu       sync.Mutex
+	notifier *notifier
+	pending  []completion
+	closed   bool
+}
+
+// completion is the result of a single asynchronous operation.
+type completion struct {
+	kind     C.int32_t
+	userData unsafe.Pointer
+	bytes    int
+	hasNext  bool
+	err      error
+}
+
+//export uplink_new_completion_queue
+// uplink_new_completion_queue creates a new completion queue.
+func uplink_new_completion_queue() C.Uplink_CompletionQueueResult {
+	notifier, err := newNotifier()
+	if err != nil {
+		return C.Uplink_CompletionQueueResult{
+			error: mallocError(err),
+		}
+	}
+
+	return C.Uplink_CompletionQueueResult{
+		queue: (*C.Uplink_CompletionQueue)(mallocHandle(universe.Add(&CompletionQueue{notifier: notifier}))),
+	}
+}
+
+//export uplink_completion_queue_fd
+// uplink_completion_queue_fd returns a file descriptor, which is readable while
+// the queue contains completions. The descriptor must only be used for polling.
+//
+// It returns -1 when the queue is not valid.
+func uplink_completion_queue_fd(queue *C.Uplink_CompletionQueue) C.int {
+	if queue == nil {
+		return -1
+	}
+
+	completions, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return -1
+	}
+
+	return C.int(completions.notifier.fd())
+}
+
+//export uplink_completion_queue_poll
+// uplink_completion_queue_poll moves up to max finished completions into completions
+// without blocking and returns their count. Every returned completion must be
+// freed with uplink_free_completion.
+func uplink_completion_queue_poll(queue *C.Uplink_CompletionQueue, completions *C.Uplink_Completion, max C.size_t) C.size_t {
+	if queue == nil || completions == nil {
+		return 0
+	}
+
+	cq, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return 0
+	}
+
+	imax, ok := safeConvertToInt(max)
+	if !ok {
+		return 0
+	}
+
+	var array []C.Uplink_Completion
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(completions)),
+		Len:  imax,
+		Cap:  imax,
+	}
+
+	done := cq.take(imax)
+	for i, c := range done {
+		array[i] = C.Uplink_Completion{
+			kind:      c.kind,
+			user_data: c.userData,
+			bytes:     C.size_t(c.bytes),
+			has_next:  C.bool(c.hasNext),
+			error:     mallocError(c.err),
+		}
+	}
+
+	return C.size_t(len(done))
+}
+
+// push adds a completion to the queue.
+func (cq *CompletionQueue) push(c completion) {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if cq.closed {
+		return
+	}
+
+	if len(cq.pending) == 0 {
+		cq.notifier.signal()
+	}
+	cq.pending = append(cq.pending, c)
+}
+
+// take removes up to max completions from the queue.
+func (cq *CompletionQueue) take(max int) []completion {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if max > len(cq.pending) {
+		max = len(cq.pending)
+	}
+
+	done := cq.pending[:max:max]
+	cq.pending = cq.pending[max:]
+	if len(cq.pending) == 0 {
+		cq.pending = nil
+		cq.notifier.clear()
+	}
+
+	return done
+}
+
+// close discards pending completions and releases the file descriptor.
+func (cq *CompletionQueue) close() {
+	cq.mu.Lock()
+	defer cq.mu.Unlock()
+
+	if cq.closed {
+		return
+	}
+
+	cq.closed = true
+	cq.pending = nil
+	cq.notifier.close()
+}
+
+//export uplink_free_completion
+// uplink_free_completion frees any resources associated with the completion.
+func uplink_free_completion(completion C.Uplink_Completion) {
+	uplink_free_error(completion.error)
+}
+
+//export uplink_free_completion_queue_result
+// uplink_free_completion_queue_result closes the queue and frees any associated resources.
+//
+// Operations that finish afterwards are discarded.
+func uplink_free_completion_queue_result(result C.Uplink_CompletionQueueResult) {
+	uplink_free_error(result.error)
+	freeCompletionQueue(result.queue)
+}
+
+func freeCompletionQueue(queue *C.Uplink_CompletionQueue) {
+	if queue == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(queue))
+	defer universe.Del(queue._handle)
+
+	cq, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if ok {
+		cq.close()
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionQueue(t *testing.T) {
+	notifier, err := newNotifier()
+	require.NoError(t, err)
+
+	cq := &CompletionQueue{notifier: notifier}
+	defer cq.close()
+
+	errTest := errors.New("test")
+	cq.push(completion{bytes: 1})
+	cq.push(completion{bytes: 2, err: errTest})
+
+	done := cq.take(1)
+	require.Len(t, done, 1)
+	require.Equal(t, 1, done[0].bytes)
+
+	done = cq.take(5)
+	require.Len(t, done, 1)
+	require.Equal(t, 2, done[0].bytes)
+	require.Equal(t, errTest, done[0].err)
+
+	var buf [8]byte
+	_, err = syscall.Read(notifier.fd(), buf[:])
+	require.Equal(t, syscall.EAGAIN, err, "descriptor should not be readable when empty")
+
+	cq.push(completion{bytes: 3})
+	n, err := syscall.Read(notifier.fd(), buf[:])
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
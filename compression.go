@@ -0,0 +1,223 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"storj.io/uplink"
+)
+
+const (
+	// compressionMetadataKey is the reserved custom metadata key, which holds
+	// the codec the object data is compressed with.
+	compressionMetadataKey = "uplink-c:compression"
+	// uncompressedSizeMetadataKey is the reserved custom metadata key, which
+	// holds the size of the data before compression.
+	uncompressedSizeMetadataKey = "uplink-c:uncompressed-size"
+
+	codecGzip = "gzip"
+)
+
+// codec compresses and decompresses object data.
+type codec struct {
+	name      string
+	newWriter func(w io.Writer) io.WriteCloser
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// codecs are the supported codecs by their Uplink_CompressionCodec value.
+var codecs = map[C.uint32_t]*codec{
+	C.UPLINK_COMPRESSION_GZIP: {
+		name:      codecGzip,
+		newWriter: func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		newReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	},
+}
+
+// codecNamed returns the codec recorded as name in the custom metadata, nil when it is not supported.
+func codecNamed(name string) *codec {
+	for _, c := range codecs {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// compressionCodec returns the codec of the object, empty when it is not compressed.
+func compressionCodec(object *uplink.Object) string {
+	if object == nil {
+		return ""
+	}
+	return object.Custom[compressionMetadataKey]
+}
+
+// checkUncompressed returns an error when the object data is compressed,
+// because random access to compressed data is not supported.
+func checkUncompressed(object *uplink.Object) error {
+	if codec := compressionCodec(object); codec != "" {
+		return ErrUnsupported.New("range access to %s compressed object", codec)
+	}
+	return nil
+}
+
+// compressedUpload compresses the written data before it is uploaded
+// and records the codec in the custom metadata on commit.
+type compressedUpload struct {
+	objectUpload
+	ctx context.Context
+
+	codec  *codec
+	mu     sync.Mutex
+	writer io.WriteCloser
+	size   int64
+	custom uplink.CustomMetadata
+}
+
+// newCompressedUpload wraps upload according to the compression option.
+func newCompressedUpload(ctx context.Context, upload objectUpload, compression C.uint32_t) (objectUpload, error) {
+	if compression == C.UPLINK_COMPRESSION_NONE {
+		return upload, nil
+	}
+	codec, ok := codecs[compression]
+	if !ok {
+		return nil, ErrUnsupported.New("compression %d", compression)
+	}
+	return compressUpload(ctx, upload, codec), nil
+}
+
+// compressUpload wraps upload to compress the written data with codec.
+func compressUpload(ctx context.Context, upload objectUpload, codec *codec) *compressedUpload {
+	return &compressedUpload{
+		objectUpload: upload,
+		ctx:          ctx,
+		codec:        codec,
+		writer:       codec.newWriter(upload),
+	}
+}
+
+// Write compresses p into the upload.
+func (upload *compressedUpload) Write(p []byte) (int, error) {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	n, err := upload.writer.Write(p)
+	upload.size += int64(n)
+	return n, err
+}
+
+// SetCustomMetadata sets custom metadata, which is extended with the codec on commit.
+func (upload *compressedUpload) SetCustomMetadata(ctx context.Context, custom uplink.CustomMetadata) error {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	if err := upload.objectUpload.SetCustomMetadata(ctx, custom); err != nil {
+		return err
+	}
+	upload.custom = custom
+	return nil
+}
+
+// Commit flushes the compressed data, stores the codec and commits the upload.
+func (upload *compressedUpload) Commit() error {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	if err := upload.writer.Close(); err != nil {
+		return err
+	}
+	if err := upload.objectUpload.SetCustomMetadata(upload.ctx, upload.metadata(upload.custom)); err != nil {
+		return err
+	}
+	return upload.objectUpload.Commit()
+}
+
+// Info returns the last information about the uploaded object including the codec.
+func (upload *compressedUpload) Info() *uplink.Object {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	info := upload.objectUpload.Info()
+	if info == nil {
+		return nil
+	}
+	object := *info
+	object.Custom = upload.metadata(info.Custom)
+	return &object
+}
+
+// metadata returns custom extended with the codec and the uncompressed size.
+func (upload *compressedUpload) metadata(custom uplink.CustomMetadata) uplink.CustomMetadata {
+	merged := uplink.CustomMetadata{}
+	for k, v := range custom {
+		merged[k] = v
+	}
+	merged[compressionMetadataKey] = upload.codec.name
+	merged[uncompressedSizeMetadataKey] = strconv.FormatInt(upload.size, 10)
+	return merged
+}
+
+// decompressedDownload transparently decompresses the downloaded data.
+type decompressedDownload struct {
+	objectDownload
+	codec  *codec
+	object *uplink.Object
+	reader io.ReadCloser
+}
+
+// newDecompressedDownload wraps download when the object is compressed. Ranges of
+// compressed objects cannot be decompressed, so they return an error.
+func newDecompressedDownload(download objectDownload, opts *uplink.DownloadOptions) (objectDownload, error) {
+	info := download.Info()
+	name := compressionCodec(info)
+	if name == "" {
+		return download, nil
+	}
+	codec := codecNamed(name)
+	if codec == nil {
+		return nil, ErrUnsupported.New("compression codec %q", name)
+	}
+	if opts != nil && (opts.Offset != 0 || (opts.Length >= 0 && opts.Length < info.System.ContentLength)) {
+		return nil, ErrUnsupported.New("range download of %s compressed object", name)
+	}
+
+	object := *info
+	if size, err := strconv.ParseInt(info.Custom[uncompressedSizeMetadataKey], 10, 64); err == nil {
+		object.System.ContentLength = size
+	}
+
+	return &decompressedDownload{
+		objectDownload: download,
+		codec:          codec,
+		object:         &object,
+	}, nil
+}
+
+// Read reads decompressed data.
+func (download *decompressedDownload) Read(p []byte) (int, error) {
+	if download.reader == nil {
+		reader, err := download.codec.newReader(download.objectDownload)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		download.reader = reader
+	}
+	return download.reader.Read(p)
+}
+
+// Info returns information about the object with the uncompressed size.
+func (download *decompressedDownload) Info() *uplink.Object {
+	return download.object
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+type memoryUpload struct {
+	bytes.Buffer
+	custom    uplink.CustomMetadata
+	committed bool
+}
+
+func (upload *memoryUpload) Commit() error { upload.committed = true; return nil }
+func (upload *memoryUpload) Abort() error  { return nil }
+func (upload *memoryUpload) Info() *uplink.Object {
+	return &uplink.Object{Custom: upload.custom}
+}
+func (upload *memoryUpload) SetCustomMetadata(ctx context.Context, custom uplink.CustomMetadata) error {
+	upload.custom = custom
+	return nil
+}
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("log line\n"), 1000)
+
+	stored := &memoryUpload{}
+	upload := compressUpload(ctx, stored, codecNamed(codecGzip))
+	require.NoError(t, upload.SetCustomMetadata(ctx, uplink.CustomMetadata{"a": "b"}))
+	_, err := upload.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, upload.Commit())
+
+	require.True(t, stored.committed)
+	require.Less(t, stored.Len(), len(data))
+	require.Equal(t, uplink.CustomMetadata{
+		"a":                         "b",
+		compressionMetadataKey:      codecGzip,
+		uncompressedSizeMetadataKey: "9000",
+	}, stored.custom)
+
+	object := &uplink.Object{Custom: stored.custom}
+	object.System.ContentLength = int64(stored.Len())
+	full := &uplink.DownloadOptions{Offset: 0, Length: -1}
+
+	download, err := newDecompressedDownload(&memoryDownload{bytes.NewReader(stored.Bytes()), object}, full)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), download.Info().System.ContentLength)
+
+	read, err := ioutil.ReadAll(download)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+
+	_, err = newDecompressedDownload(&memoryDownload{bytes.NewReader(nil), object}, &uplink.DownloadOptions{Offset: 10, Length: -1})
+	require.True(t, ErrUnsupported.Has(err))
+
+	object.Custom = uplink.CustomMetadata{compressionMetadataKey: "zstd"}
+	_, err = newDecompressedDownload(&memoryDownload{bytes.NewReader(nil), object}, full)
+	require.True(t, ErrUnsupported.Has(err))
+}
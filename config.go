@@ -50,6 +50,14 @@ func uplink_config_request_access_with_passphrase(config C.Uplink_Config, satell
 //export uplink_config_open_project
 // uplink_config_open_project opens project using access grant.
 func uplink_config_open_project(config C.Uplink_Config, access *C.Uplink_Access) C.Uplink_ProjectResult {
+	return uplink_config_open_project_with_cancel(config, access, nil)
+}
+
+//export uplink_config_open_project_with_cancel
+// uplink_config_open_project_with_cancel opens project using access grant.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_config_open_project_with_cancel(config C.Uplink_Config, access *C.Uplink_Access, token *C.Uplink_CancelToken) C.Uplink_ProjectResult {
 	if access == nil {
 		return C.Uplink_ProjectResult{
 			error: mallocError(ErrNull.New("access")),
@@ -63,18 +71,35 @@ func uplink_config_open_project(config C.Uplink_Config, access *C.Uplink_Access)
 		}
 	}
 
+	bandwidth, err := configBandwidthLimiter(config.bandwidth_limiter)
+	if err != nil {
+		return C.Uplink_ProjectResult{
+			error: mallocError(err),
+		}
+	}
+
 	scope := rootScope(C.GoString(config.temp_directory))
 
+	dial, err := scope.childWithCancel(token)
+	if err != nil {
+		scope.cancel()
+		return C.Uplink_ProjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer dial.cancel()
+
 	cfg := uplinkConfig(config)
-	proj, err := cfg.OpenProject(scope.ctx, acc.Access)
+	proj, err := cfg.OpenProject(dial.ctx, acc.Access)
 	if err != nil {
+		scope.cancel()
 		return C.Uplink_ProjectResult{
 			error: mallocError(err),
 		}
 	}
 
 	return C.Uplink_ProjectResult{
-		project: (*C.Uplink_Project)(mallocHandle(universe.Add(&Project{scope, proj}))),
+		project: (*C.Uplink_Project)(mallocHandle(universe.Add(&Project{scope: scope, Project: proj, tempDir: C.GoString(config.temp_directory), journalScope: journalScope(acc.Access), bandwidth: bandwidth}))),
 	}
 }
 
@@ -0,0 +1,194 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"io"
+	"time"
+
+	"storj.io/uplink"
+)
+
+//export uplink_copy_object
+// uplink_copy_object copies the object at src_key to dst_key by streaming its data
+// through the client. Custom metadata and expiration are preserved unless options
+// override them.
+func uplink_copy_object(project *C.Uplink_Project, src_bucket, src_key, dst_bucket, dst_key *C.char, options *C.Uplink_CopyObjectOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_copy_object_with_cancel(project, src_bucket, src_key, dst_bucket, dst_key, options, nil)
+}
+
+//export uplink_copy_object_with_cancel
+// uplink_copy_object_with_cancel copies the object at src_key to dst_key by streaming its data
+// through the client. Custom metadata and expiration are preserved unless options
+// override them.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_copy_object_with_cancel(project *C.Uplink_Project, src_bucket, src_key, dst_bucket, dst_key *C.char, options *C.Uplink_CopyObjectOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_copy_object_across_projects_with_cancel(project, src_bucket, src_key, project, dst_bucket, dst_key, options, token)
+}
+
+//export uplink_copy_object_across_projects
+// uplink_copy_object_across_projects copies the object at src_key in src_project to dst_key
+// in dst_project. The projects may use different access grants and satellites.
+func uplink_copy_object_across_projects(src_project *C.Uplink_Project, src_bucket, src_key *C.char, dst_project *C.Uplink_Project, dst_bucket, dst_key *C.char, options *C.Uplink_CopyObjectOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_copy_object_across_projects_with_cancel(src_project, src_bucket, src_key, dst_project, dst_bucket, dst_key, options, nil)
+}
+
+//export uplink_copy_object_across_projects_with_cancel
+// uplink_copy_object_across_projects_with_cancel copies the object at src_key in src_project
+// to dst_key in dst_project. The projects may use different access grants and satellites.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_copy_object_across_projects_with_cancel(src_project *C.Uplink_Project, src_bucket, src_key *C.char, dst_project *C.Uplink_Project, dst_bucket, dst_key *C.char, options *C.Uplink_CopyObjectOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if src_project == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("src_project")),
+		}
+	}
+	if src_bucket == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("src_bucket")),
+		}
+	}
+	if src_key == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("src_key")),
+		}
+	}
+	if dst_project == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("dst_project")),
+		}
+	}
+	if dst_bucket == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("dst_bucket")),
+		}
+	}
+	if dst_key == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("dst_key")),
+		}
+	}
+
+	src, ok := universe.Get(src_project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrInvalidHandle.New("src_project")),
+		}
+	}
+	dst, ok := universe.Get(dst_project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrInvalidHandle.New("dst_project")),
+		}
+	}
+
+	scope, err := src.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+	if dst != src {
+		scope = scope.childWithContext(dst.scope.ctx)
+		defer scope.cancel()
+	}
+
+	_, object, err := copyObject(scope.ctx, src, C.GoString(src_bucket), C.GoString(src_key), dst, C.GoString(dst_bucket), C.GoString(dst_key), copyOverrides(options))
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+// copyOverride changes the properties of a copied object.
+type copyOverride struct {
+	expires time.Time
+	// keepExpires keeps the expiration of the source when set.
+	keepExpires bool
+	// custom replaces the custom metadata of the source when not nil,
+	// the reserved keys of the source are always kept.
+	custom uplink.CustomMetadata	// verify checks the checksums of an uncompressed source while it is copied.
+	verify bool
+}
+
+// metadata returns the custom metadata of a copy of an object with custom. The data is
+// copied as stored, so the reserved keys, such as its codec and checksums, are kept.
+func (override copyOverride) metadata(custom uplink.CustomMetadata) uplink.CustomMetadata {
+	if override.custom == nil {
+		return custom
+	}
+	return updatedMetadata(custom, override.custom, false)
+}
+
+func copyOverrides(options *C.Uplink_CopyObjectOptions) copyOverride {
+	override := copyOverride{keepExpires: true}
+	if options == nil {
+		return override
+	}
+
+	switch {
+	case options.expires > 0:
+		override.keepExpires = false
+		override.expires = time.Unix(int64(options.expires), 0)
+	case options.expires < 0:
+		override.keepExpires = false
+	}
+	if options.custom != nil {
+		override.custom = customMetadataFromC(*options.custom)
+	}
+	return override
+}
+
+// copyObject streams the stored data of the source object into a new object and returns
+// the source and the copy. Objects uploaded in parts are reassembled into a single object
+// and compressed data is copied as is together with its codec.
+func copyObject(ctx context.Context, src *Project, srcBucket, srcKey string, dst *Project, dstBucket, dstKey string, override copyOverride) (source, copied *uplink.Object, err error) {
+	download, err := openDownload(ctx, src.Project, srcBucket, srcKey, &uplink.DownloadOptions{Offset: 0, Length: -1}, retryPolicy{}, parallelPolicy{}, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = download.Close() }()
+
+	source = download.Info()
+	if override.verify && compressionCodec(source) == "" {
+		// the stored data of uncompressed objects is what the checksums were computed from.
+		download = newChecksumDownload(download, nil)
+	}
+	download = throttleDownload(ctx, download, src.bandwidth.download)
+	opts := &uplink.UploadOptions{Expires: override.expires}
+	if override.keepExpires {
+		opts.Expires = source.System.Expires
+	}
+	custom := override.metadata(source.Custom)
+
+	upload, err := dst.UploadObject(ctx, dstBucket, dstKey, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	throttled := throttleUpload(ctx, upload, dst.bandwidth.upload)
+
+	if err := upload.SetCustomMetadata(ctx, custom); err != nil {
+		_ = upload.Abort()
+		return nil, nil, err
+	}
+	if _, err := io.Copy(throttled, download); err != nil {
+		_ = upload.Abort()
+		return nil, nil, err
+	}
+	if err := commitReplacing(ctx, dst.Project, dstBucket, dstKey, "", upload.Commit); err != nil {
+		return nil, nil, err
+	}
+
+	copied, err = publicObject(ctx, dst.Project, dstBucket, upload.Info(), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, copied, nil
+}
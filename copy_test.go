@@ -0,0 +1,38 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestCopyOverrideMetadata(t *testing.T) {
+	compressed := uplink.CustomMetadata{
+		"color":                           "blue",
+		compressionMetadataKey:            codecGzip,
+		uncompressedSizeMetadataKey:       "1000",
+		checksumMetadataPrefix + "sha256": "abcd",
+	}
+
+	kept := copyOverride{keepExpires: true}
+	require.Equal(t, compressed, kept.metadata(compressed))
+
+	replaced := copyOverride{
+		keepExpires: true,
+		custom: uplink.CustomMetadata{
+			"shape":                "round",
+			compressionMetadataKey: "none",
+		},
+	}
+	require.Equal(t, uplink.CustomMetadata{
+		"shape":                           "round",
+		compressionMetadataKey:            codecGzip,
+		uncompressedSizeMetadataKey:       "1000",
+		checksumMetadataPrefix + "sha256": "abcd",
+	}, replaced.metadata(compressed))
+}
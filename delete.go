@@ -0,0 +1,209 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"reflect"
+	"unsafe"
+
+	"storj.io/uplink"
+)
+
+// defaultDeleteConcurrency is the number of concurrent deletes when options don't specify it.
+const defaultDeleteConcurrency = 8
+
+// deleteBatchSize is the number of listed objects deleted together when deleting a prefix.
+const deleteBatchSize = 1000
+
+//export uplink_delete_prefix
+// uplink_delete_prefix deletes every object under prefix, including objects in nested prefixes.
+// When prefix is empty, all objects in the bucket are deleted.
+//
+// Failed deletes don't stop the operation, they are returned in the failures list.
+// The error is only set when the objects could not be listed.
+func uplink_delete_prefix(project *C.Uplink_Project, bucket_name, prefix *C.char, options *C.Uplink_DeletePrefixOptions) C.Uplink_DeletePrefixResult { //nolint:golint
+	return uplink_delete_prefix_with_cancel(project, bucket_name, prefix, options, nil)
+}
+
+//export uplink_delete_prefix_with_cancel
+// uplink_delete_prefix_with_cancel deletes every object under prefix, including objects in nested prefixes.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_delete_prefix_with_cancel(project *C.Uplink_Project, bucket_name, prefix *C.char, options *C.Uplink_DeletePrefixOptions, token *C.Uplink_CancelToken) C.Uplink_DeletePrefixResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_DeletePrefixResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_DeletePrefixResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+	if prefix == nil {
+		return C.Uplink_DeletePrefixResult{
+			error: mallocError(ErrNull.New("prefix")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_DeletePrefixResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_DeletePrefixResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	concurrency := defaultDeleteConcurrency
+	if options != nil && options.concurrency > 0 {
+		concurrency = int(options.concurrency)
+	}
+
+	deleted, failures, err := deletePrefix(scope.ctx, proj.Project, C.GoString(bucket_name), C.GoString(prefix), concurrency)
+	cfailures, count := mallocDeleteFailures(failures)
+	return C.Uplink_DeletePrefixResult{
+		deleted:        C.int64_t(deleted),
+		failures:       cfailures,
+		failures_count: count,
+		error:          mallocError(err),
+	}
+}
+
+//export uplink_free_delete_prefix_result
+// uplink_free_delete_prefix_result frees any resources associated with delete prefix result.
+func uplink_free_delete_prefix_result(result C.Uplink_DeletePrefixResult) {
+	uplink_free_error(result.error)
+	freeDeleteFailures(result.failures, result.failures_count)
+}
+
+// deleteFailure is an object that could not be deleted.
+type deleteFailure struct {
+	key string
+	err error
+}
+
+// deletePrefix deletes all objects under prefix with concurrent deletes. It returns
+// the number of deleted objects and the failed deletes. The error is only set when
+// listing fails.
+//
+// Objects uploaded in parts are deleted together with their parts, which are not
+// counted as separate objects.
+func deletePrefix(ctx context.Context, project *uplink.Project, bucket, prefix string, concurrency int) (int64, []deleteFailure, error) {
+	iterator := project.ListObjects(ctx, bucket, &uplink.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+		Custom:    true,
+	})
+
+	var deleted int64
+	var failures []deleteFailure
+
+	batch := make([]*uplink.Object, 0, deleteBatchSize)
+	for {
+		batch = batch[:0]
+		for len(batch) < deleteBatchSize && iterator.Next() {
+			batch = append(batch, iterator.Item())
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		batchDeleted, batchFailures := deleteObjects(ctx, project, bucket, batch, concurrency)
+		deleted += batchDeleted
+		failures = append(failures, batchFailures...)
+	}
+
+	return deleted, failures, iterator.Err()
+}
+
+// deleteObjects deletes the objects with concurrent deletes.
+func deleteObjects(ctx context.Context, project *uplink.Project, bucket string, objects []*uplink.Object, concurrency int) (int64, []deleteFailure) {
+	counted := make([]bool, len(objects))
+	deleteErrs := make([]error, len(objects))
+	runConcurrently(len(objects), concurrency, func(i int) {
+		counted[i], deleteErrs[i] = deletePrefixObject(ctx, project, bucket, objects[i])
+	})
+
+	var deleted int64
+	var failures []deleteFailure
+	for i, object := range objects {
+		if deleteErrs[i] != nil {
+			failures = append(failures, deleteFailure{key: object.Key, err: deleteErrs[i]})
+		} else if counted[i] {
+			deleted++
+		}
+	}
+	return deleted, failures
+}
+
+// mallocDeleteFailures allocates a C array of the failures.
+func mallocDeleteFailures(failures []deleteFailure) (*C.Uplink_DeleteFailure, C.size_t) {
+	if len(failures) == 0 {
+		return nil, 0
+	}
+
+	cfailures := (*C.Uplink_DeleteFailure)(C.calloc(C.size_t(len(failures)), C.sizeof_Uplink_DeleteFailure))
+
+	var array []C.Uplink_DeleteFailure
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(cfailures)),
+		Len:  len(failures),
+		Cap:  len(failures),
+	}
+
+	for i, failure := range failures {
+		array[i] = C.Uplink_DeleteFailure{
+			key:   C.CString(failure.key),
+			error: mallocError(failure.err),
+		}
+	}
+
+	return cfailures, C.size_t(len(failures))
+}
+
+// freeDeleteFailures frees a C array of failures.
+func freeDeleteFailures(failures *C.Uplink_DeleteFailure, count C.size_t) {
+	if failures == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(failures))
+
+	var array []C.Uplink_DeleteFailure
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(unsafe.Pointer(failures)),
+		Len:  int(count),
+		Cap:  int(count),
+	}
+
+	for _, failure := range array {
+		C.free(unsafe.Pointer(failure.key))
+		uplink_free_error(failure.error)
+	}
+}
+
+// deletePrefixObject deletes a listed object and reports whether it counts as
+// a deleted object. Parts may already be deleted together with their manifest.
+func deletePrefixObject(ctx context.Context, project *uplink.Project, bucket string, object *uplink.Object) (counted bool, err error) {
+	if isPartKey(object.Key) {
+		_, err := project.DeleteObject(ctx, bucket, object.Key)
+		if errors.Is(err, uplink.ErrObjectNotFound) {
+			err = nil
+		}
+		return false, err
+	}
+
+	_, err = deleteObjectWithParts(ctx, project, bucket, object)
+	return err == nil, err
+}
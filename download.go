@@ -6,6 +6,9 @@ package main
 // #include "uplink_definitions.h"
 import "C"
 import (
+	"context"
+	"errors"
+	"io"
 	"reflect"
 	"unsafe"
 
@@ -15,12 +18,22 @@ import (
 // Download is a partial download to Storj Network.
 type Download struct {
 	scope
-	download *uplink.Download
+	download objectDownload
+	async    asyncQueue
+	progress *progress
 }
 
 //export uplink_download_object
 // uplink_download_object starts  download to the specified key.
 func uplink_download_object(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_DownloadOptions) C.Uplink_DownloadResult { //nolint:golint
+	return uplink_download_object_with_cancel(project, bucket_name, object_key, options, nil)
+}
+
+//export uplink_download_object_with_cancel
+// uplink_download_object_with_cancel starts  download to the specified key.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_download_object_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_DownloadOptions, token *C.Uplink_CancelToken) C.Uplink_DownloadResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_DownloadResult{
 			error: mallocError(ErrNull.New("project")),
@@ -43,26 +56,29 @@ func uplink_download_object(project *C.Uplink_Project, bucket_name, object_key *
 			error: mallocError(ErrInvalidHandle.New("project")),
 		}
 	}
-	scope := proj.scope.child()
-
-	opts := &uplink.DownloadOptions{
-		Offset: 0,
-		Length: -1,
-	}
-	if options != nil {
-		opts.Offset = int64(options.offset)
-		opts.Length = int64(options.length)
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_DownloadResult{
+			error: mallocError(err),
+		}
 	}
 
-	download, err := proj.DownloadObject(scope.ctx, C.GoString(bucket_name), C.GoString(object_key), opts)
+	opts := downloadOptions(options)
+	download, err := openDownload(scope.ctx, proj.Project, C.GoString(bucket_name), C.GoString(object_key), opts, downloadRetryPolicy(options), downloadParallelPolicy(options), downloadRaw(options))
 	if err != nil {
+		scope.cancel()
 		return C.Uplink_DownloadResult{
 			error: mallocError(err),
 		}
 	}
 
+	download = throttleDownload(scope.ctx, download, proj.downloadLimiter(options))
+
+	progress := downloadProgress(options)
+	progress.SetTotal(downloadLength(download.Info().System.ContentLength, opts))
+
 	return C.Uplink_DownloadResult{
-		download: (*C.Uplink_Download)(mallocHandle(universe.Add(&Download{scope, download}))),
+		download: (*C.Uplink_Download)(mallocHandle(universe.Add(&Download{scope: scope, download: download, progress: progress}))),
 	}
 }
 
@@ -92,13 +108,106 @@ func uplink_download_read(download *C.Uplink_Download, bytes unsafe.Pointer, len
 		Cap:  ilength,
 	}
 
-	n, err := down.download.Read(buf)
+	n, err := down.read(buf)
 	return C.Uplink_ReadResult{
 		bytes_read: C.size_t(n),
 		error:      mallocError(err),
 	}
 }
 
+//export uplink_download_read_async
+// uplink_download_read_async starts downloading from object's data stream into bytes up to length amount.
+//
+// The callback is invoked with the read result once the read finishes and bytes must stay
+// valid until then. When the read cannot be started, an error is returned and the
+// callback is not invoked.
+func uplink_download_read_async(download *C.Uplink_Download, bytes unsafe.Pointer, length C.size_t, callback C.Uplink_ReadCallback, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if download == nil {
+		return mallocError(ErrNull.New("download"))
+	}
+	if callback == nil {
+		return mallocError(ErrNull.New("callback"))
+	}
+
+	down, ok := universe.Get(download._handle).(*Download)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("download"))
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return mallocError(ErrInvalidArg.New("length too large"))
+	}
+
+	down.readAsync(bytes, ilength, func(n int, err error) {
+		callReadCallback(callback, C.Uplink_ReadResult{
+			bytes_read: C.size_t(n),
+			error:      mallocError(err),
+		}, user_data)
+	})
+
+	return nil
+}
+
+//export uplink_download_read_queued
+// uplink_download_read_queued starts downloading from object's data stream into bytes up to length amount.
+//
+// Once the read finishes a completion with user_data is added to the queue and bytes must
+// stay valid until then. When the read cannot be started, an error is returned and no
+// completion is added.
+func uplink_download_read_queued(download *C.Uplink_Download, bytes unsafe.Pointer, length C.size_t, queue *C.Uplink_CompletionQueue, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if download == nil {
+		return mallocError(ErrNull.New("download"))
+	}
+	if queue == nil {
+		return mallocError(ErrNull.New("queue"))
+	}
+
+	down, ok := universe.Get(download._handle).(*Download)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("download"))
+	}
+	completions, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("queue"))
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return mallocError(ErrInvalidArg.New("length too large"))
+	}
+
+	down.readAsync(bytes, ilength, func(n int, err error) {
+		completions.push(completion{
+			kind:     C.UPLINK_COMPLETION_READ,
+			userData: user_data,
+			bytes:    n,
+			err:      err,
+		})
+	})
+
+	return nil
+}
+
+// readAsync reads into bytes in the background and calls done with the result.
+func (down *Download) readAsync(bytes unsafe.Pointer, length int, done func(n int, err error)) {
+	down.async.Go(func() {
+		if err := down.scope.ctx.Err(); err != nil {
+			done(0, err)
+			return
+		}
+
+		var buf []byte
+		*(*reflect.SliceHeader)(unsafe.Pointer(&buf)) = reflect.SliceHeader{
+			Data: uintptr(bytes),
+			Len:  length,
+			Cap:  length,
+		}
+
+		done(down.read(buf))
+	})
+}
+
 //export uplink_download_info
 // uplink_download_info returns information about the downloaded object.
 func uplink_download_info(download *C.Uplink_Download) C.Uplink_ObjectResult {
@@ -143,6 +252,94 @@ func uplink_free_download_result(result C.Uplink_DownloadResult) {
 	freeDownload(result.download)
 }
 
+// read reads from the download and reports the progress.
+func (down *Download) read(buf []byte) (int, error) {
+	n, err := down.download.Read(buf)
+	down.progress.Add(n)
+	if errors.Is(err, io.EOF) {
+		down.progress.Finish()
+	}
+	return n, err
+}
+
+// objectDownload is the download of an object.
+type objectDownload interface {
+	io.ReadCloser
+	Info() *uplink.Object
+}
+
+// openDownload starts a download, which retries failed reads according to retry.
+// When parallel is enabled, the object is downloaded in concurrent parts.
+// Objects uploaded in parts are transparently reassembled. Unless raw is set,
+// compressed objects are decompressed and stored checksums are verified when
+// the whole object is downloaded.
+func openDownload(ctx context.Context, project *uplink.Project, bucket, key string, opts *uplink.DownloadOptions, retry retryPolicy, parallel parallelPolicy, raw bool) (objectDownload, error) {
+	download, err := openObjectDownload(ctx, project, bucket, key, opts, retry, parallel)
+	if err != nil {
+		return nil, err
+	}
+	if raw {
+		return download, nil
+	}
+
+	decompressed, err := newDecompressedDownload(download, opts)
+	if err != nil {
+		_ = download.Close()
+		return nil, err
+	}
+	return newChecksumDownload(decompressed, opts), nil
+}
+
+func openObjectDownload(ctx context.Context, project *uplink.Project, bucket, key string, opts *uplink.DownloadOptions, retry retryPolicy, parallel parallelPolicy) (objectDownload, error) {
+	if parallel.enabled() {
+		object, segments, err := resolveSegments(ctx, project, bucket, key, opts, parallel.partSize)
+		if err != nil {
+			return nil, err
+		}
+		return newParallelDownload(ctx, object, segments, projectSegmentDownloader(project, bucket, retry), parallel.concurrency), nil
+	}
+
+	download, err := openRetryingDownload(ctx, project, bucket, key, opts, retry)
+	if err != nil {
+		return nil, err
+	}
+	if !isManifest(download.Info()) {
+		return download, nil
+	}
+	_ = download.Close()
+
+	object, segments, err := resolveManifest(ctx, project, bucket, download.Info(), opts, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newSequentialDownload(ctx, project, bucket, object, segments, retry), nil
+}
+
+// downloadLimiter returns the rate limiter for a download with options.
+func (proj *Project) downloadLimiter(options *C.Uplink_DownloadOptions) *rateLimiter {
+	if options == nil {
+		return proj.bandwidth.download
+	}
+	return transferLimiter(proj.bandwidth.download, options.bytes_per_second)
+}
+
+// downloadRaw returns whether options request the stored bytes without decoding.
+func downloadRaw(options *C.Uplink_DownloadOptions) bool {
+	return options != nil && bool(options.raw)
+}
+
+func downloadOptions(options *C.Uplink_DownloadOptions) *uplink.DownloadOptions {
+	opts := &uplink.DownloadOptions{
+		Offset: 0,
+		Length: -1,
+	}
+	if options != nil {
+		opts.Offset = int64(options.offset)
+		opts.Length = int64(options.length)
+	}
+	return opts
+}
+
 // freeDownload closes the download and frees any associated resources.
 func freeDownload(download *C.Uplink_Download) {
 	if download == nil {
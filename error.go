@@ -24,6 +24,14 @@ var (
 	ErrNull = errs.Class("NULL")
 	// ErrInvalidArg is returned when the argument is not valid.
 	ErrInvalidArg = errs.Class("invalid argument")
+	// ErrObjectChanged is returned when the object was modified while it was being read.
+	ErrObjectChanged = errs.Class("object changed")
+	// ErrChecksumMismatch is returned when the downloaded data does not match the stored checksums.
+	ErrChecksumMismatch = errs.Class("checksum mismatch")
+	// ErrMoveIncomplete is returned when an object was copied, but the move could not be finished.
+	ErrMoveIncomplete = errs.Class("move incomplete")
+	// ErrUnsupported is returned when the operation is not supported for the object.
+	ErrUnsupported = errs.Class("unsupported operation")
 )
 
 func mallocError(err error) *C.Uplink_Error {
@@ -39,8 +47,12 @@ func mallocError(err error) *C.Uplink_Error {
 		return cerror
 	case errors.Is(err, context.Canceled):
 		cerror.code = C.UPLINK_ERROR_CANCELED
+	case errors.Is(err, context.DeadlineExceeded):
+		cerror.code = C.UPLINK_ERROR_DEADLINE_EXCEEDED
 	case ErrInvalidHandle.Has(err):
 		cerror.code = C.UPLINK_ERROR_INVALID_HANDLE
+	case ErrUnsupported.Has(err):
+		cerror.code = C.UPLINK_ERROR_UNSUPPORTED
 
 	case errors.Is(err, uplink.ErrTooManyRequests):
 		cerror.code = C.UPLINK_ERROR_TOO_MANY_REQUESTS
@@ -62,6 +74,12 @@ func mallocError(err error) *C.Uplink_Error {
 		cerror.code = C.UPLINK_ERROR_OBJECT_NOT_FOUND
 	case errors.Is(err, uplink.ErrUploadDone):
 		cerror.code = C.UPLINK_ERROR_UPLOAD_DONE
+	case ErrObjectChanged.Has(err):
+		cerror.code = C.UPLINK_ERROR_OBJECT_CHANGED
+	case ErrChecksumMismatch.Has(err):
+		cerror.code = C.UPLINK_ERROR_CHECKSUM_MISMATCH
+	case ErrMoveIncomplete.Has(err):
+		cerror.code = C.UPLINK_ERROR_MOVE_INCOMPLETE
 
 	default:
 		cerror.code = C.UPLINK_ERROR_INTERNAL
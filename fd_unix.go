@@ -0,0 +1,25 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/zeebo/errs"
+)
+
+// openFD returns a file referring to the same file as fd, without taking ownership of fd.
+func openFD(fd int) (*os.File, error) {
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	syscall.CloseOnExec(dup)
+
+	return os.NewFile(uintptr(dup), "fd"), nil
+}
@@ -0,0 +1,18 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+//go:build windows
+// +build windows
+
+package main
+
+import (
+	"os"
+
+	"github.com/zeebo/errs"
+)
+
+// openFD is not available on windows, since C runtime descriptors are not os handles.
+func openFD(fd int) (*os.File, error) {
+	return nil, errs.New("file descriptors are not supported on windows")
+}
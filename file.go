@@ -0,0 +1,339 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/uplink"
+)
+
+//export uplink_upload_file
+// uplink_upload_file uploads the file at path to the specified key and commits it.
+func uplink_upload_file(project *C.Uplink_Project, bucket_name, object_key, path *C.char, options *C.Uplink_UploadOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_upload_file_with_cancel(project, bucket_name, object_key, path, options, nil)
+}
+
+//export uplink_upload_file_with_cancel
+// uplink_upload_file_with_cancel uploads the file at path to the specified key and commits it.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_upload_file_with_cancel(project *C.Uplink_Project, bucket_name, object_key, path *C.char, options *C.Uplink_UploadOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if path == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("path")),
+		}
+	}
+
+	file, err := os.Open(C.GoString(path))
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(errs.Wrap(err)),
+		}
+	}
+	defer func() { _ = file.Close() }()
+
+	object, err := uploadFrom(project, bucket_name, object_key, file, options, token, false)
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+//export uplink_upload_fd
+// uplink_upload_fd uploads everything from the current position of file descriptor fd
+// to the specified key and commits it. The file descriptor is not closed.
+func uplink_upload_fd(project *C.Uplink_Project, bucket_name, object_key *C.char, fd C.int, options *C.Uplink_UploadOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_upload_fd_with_cancel(project, bucket_name, object_key, fd, options, nil)
+}
+
+//export uplink_upload_fd_with_cancel
+// uplink_upload_fd_with_cancel uploads everything from the current position of file descriptor fd
+// to the specified key and commits it. The file descriptor is not closed.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_upload_fd_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, fd C.int, options *C.Uplink_UploadOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if fd < 0 {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrInvalidArg.New("fd")),
+		}
+	}
+
+	file, err := openFD(int(fd))
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer func() { _ = file.Close() }()
+
+	object, err := uploadFrom(project, bucket_name, object_key, file, options, token, false)
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+//export uplink_resume_upload_file
+// uplink_resume_upload_file continues an interrupted resumable upload of the file at path
+// to the specified key and commits it. The file must not have been modified.
+func uplink_resume_upload_file(project *C.Uplink_Project, bucket_name, object_key, path *C.char, options *C.Uplink_UploadOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_resume_upload_file_with_cancel(project, bucket_name, object_key, path, options, nil)
+}
+
+//export uplink_resume_upload_file_with_cancel
+// uplink_resume_upload_file_with_cancel continues an interrupted resumable upload of the file at path
+// to the specified key and commits it. The file must not have been modified.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_resume_upload_file_with_cancel(project *C.Uplink_Project, bucket_name, object_key, path *C.char, options *C.Uplink_UploadOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if path == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("path")),
+		}
+	}
+
+	file, err := os.Open(C.GoString(path))
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(errs.Wrap(err)),
+		}
+	}
+	defer func() { _ = file.Close() }()
+
+	object, err := uploadFrom(project, bucket_name, object_key, file, options, token, true)
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+//export uplink_download_to_file
+// uplink_download_to_file downloads the object at the specified key into the file at path.
+//
+// The data is first written into a temporary file next to path, which replaces
+// the file at path only when the download succeeds.
+func uplink_download_to_file(project *C.Uplink_Project, bucket_name, object_key, path *C.char, options *C.Uplink_DownloadOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_download_to_file_with_cancel(project, bucket_name, object_key, path, options, nil)
+}
+
+//export uplink_download_to_file_with_cancel
+// uplink_download_to_file_with_cancel downloads the object at the specified key into the file at path.
+//
+// The data is first written into a temporary file next to path, which replaces
+// the file at path only when the download succeeds.
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_download_to_file_with_cancel(project *C.Uplink_Project, bucket_name, object_key, path *C.char, options *C.Uplink_DownloadOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if path == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("path")),
+		}
+	}
+
+	object, err := downloadToFile(C.GoString(path), func(file *os.File) (*uplink.Object, error) {
+		return downloadTo(project, bucket_name, object_key, file, options, token)
+	})
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+//export uplink_download_fd
+// uplink_download_fd downloads the object at the specified key into file descriptor fd,
+// starting at its current position. The file descriptor is not closed.
+func uplink_download_fd(project *C.Uplink_Project, bucket_name, object_key *C.char, fd C.int, options *C.Uplink_DownloadOptions) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_download_fd_with_cancel(project, bucket_name, object_key, fd, options, nil)
+}
+
+//export uplink_download_fd_with_cancel
+// uplink_download_fd_with_cancel downloads the object at the specified key into file descriptor fd,
+// starting at its current position. The file descriptor is not closed.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_download_fd_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, fd C.int, options *C.Uplink_DownloadOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if fd < 0 {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrInvalidArg.New("fd")),
+		}
+	}
+
+	file, err := openFD(int(fd))
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer func() { _ = file.Close() }()
+
+	object, err := downloadTo(project, bucket_name, object_key, file, options, token)
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+// downloadToFile calls download with a temporary file next to path
+// and replaces the file at path with it when download succeeds.
+func downloadToFile(path string, download func(file *os.File) (*uplink.Object, error)) (_ *uplink.Object, err error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	object, err := download(tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, errs.Wrap(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, errs.Wrap(err)
+	}
+
+	return object, nil
+}
+
+// uploadFrom uploads everything from the current position of file to the specified key and commits it.
+//
+// When resume is true, it continues an interrupted resumable upload and file is read from
+// the position where the upload was interrupted.
+func uploadFrom(project *C.Uplink_Project, bucket_name, object_key *C.char, file *os.File, options *C.Uplink_UploadOptions, token *C.Uplink_CancelToken, resume bool) (*uplink.Object, error) { //nolint:golint
+	if project == nil {
+		return nil, ErrNull.New("project")
+	}
+	if bucket_name == nil {
+		return nil, ErrNull.New("bucket_name")
+	}
+	if object_key == nil {
+		return nil, ErrNull.New("object_key")
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return nil, ErrInvalidHandle.New("project")
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return nil, err
+	}
+	defer scope.cancel()
+
+	var upload objectUpload
+	if resume {
+		var offset int64
+		upload, offset, err = continueUpload(scope.ctx, proj, C.GoString(bucket_name), C.GoString(object_key), options)
+		if err == nil {
+			if _, err = file.Seek(offset, io.SeekStart); err != nil {
+				abandonUpload(upload)
+				err = errs.Wrap(err)
+			}
+		}
+	} else {
+		upload, err = startUpload(scope.ctx, proj, C.GoString(bucket_name), C.GoString(object_key), options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	progress := uploadProgress(options)
+	progress.SetTotal(remainingSize(file))
+
+	if _, err := io.Copy(progress.Writer(upload), file); err != nil {
+		abandonUpload(upload)
+		return nil, err
+	}
+
+	if err := upload.Commit(); err != nil {
+		return nil, err
+	}
+	progress.Finish()
+
+	return upload.Info(), nil
+}
+
+// downloadTo downloads the object at the specified key into destination.
+func downloadTo(project *C.Uplink_Project, bucket_name, object_key *C.char, destination io.Writer, options *C.Uplink_DownloadOptions, token *C.Uplink_CancelToken) (*uplink.Object, error) { //nolint:golint
+	if project == nil {
+		return nil, ErrNull.New("project")
+	}
+	if bucket_name == nil {
+		return nil, ErrNull.New("bucket_name")
+	}
+	if object_key == nil {
+		return nil, ErrNull.New("object_key")
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return nil, ErrInvalidHandle.New("project")
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return nil, err
+	}
+	defer scope.cancel()
+
+	opts := downloadOptions(options)
+	download, err := openDownload(scope.ctx, proj.Project, C.GoString(bucket_name), C.GoString(object_key), opts, downloadRetryPolicy(options), downloadParallelPolicy(options), downloadRaw(options))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = download.Close() }()
+	download = throttleDownload(scope.ctx, download, proj.downloadLimiter(options))
+
+	progress := downloadProgress(options)
+	progress.SetTotal(downloadLength(download.Info().System.ContentLength, opts))
+
+	if _, err := io.Copy(destination, progress.Reader(download)); err != nil {
+		return nil, err
+	}
+	progress.Finish()
+
+	return download.Info(), nil
+}
+
+// remainingSize returns the number of bytes from the current position
+// until the end of file, or -1 when it is not a regular file.
+func remainingSize(file *os.File) int64 {
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return -1
+	}
+
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return -1
+	}
+
+	return info.Size() - offset
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestDownloadToFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uplink-c")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "data.txt")
+	require.NoError(t, ioutil.WriteFile(path, []byte("original"), 0600))
+
+	{ // failed download keeps the original file
+		errTest := errors.New("test")
+		_, err := downloadToFile(path, func(file *os.File) (*uplink.Object, error) {
+			_, _ = file.WriteString("partial")
+			return nil, errTest
+		})
+		require.Equal(t, errTest, err)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "original", string(data))
+	}
+
+	{ // successful download replaces the file
+		object, err := downloadToFile(path, func(file *os.File) (*uplink.Object, error) {
+			_, err := file.WriteString("replaced")
+			return &uplink.Object{Key: "data.txt"}, err
+		})
+		require.NoError(t, err)
+		require.Equal(t, "data.txt", object.Key)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "replaced", string(data))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "temporary files should be removed")
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/uplink"
+)
+
+// uploadJournal records the progress of a resumable upload on disk,
+// so that it can be continued after the process restarts.
+type uploadJournal struct {
+	path string
+
+	mu    sync.Mutex
+	state journalState
+}
+
+// journalState is the persisted part of the journal.
+type journalState struct {
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	UploadID  string    `json:"upload_id"`
+	PartSize  int64     `json:"part_size"`
+	Expires   time.Time `json:"expires"`
+	Checksums uint32    `json:"checksums,omitempty"`
+	Completed []int     `json:"completed"`
+}
+
+// journalDir returns the directory for journals based on the temp directory of the project.
+func journalDir(tempDir string) (string, error) {
+	switch tempDir {
+	case "inmemory":
+		return "", ErrInvalidArg.New("resumable uploads require a temp_directory")
+	case "":
+		return os.TempDir(), nil
+	}
+	return tempDir, nil
+}
+
+// journalPath returns where the journal for the upload to bucket and key is stored.
+// Journals are scoped by the access the project was opened with, since the directory
+// may be shared by different projects and processes.
+func journalPath(dir, scope, bucket, key string) string {
+	hash := sha256.Sum256([]byte(scope + "/" + bucket + "/" + key))
+	return filepath.Join(dir, "uplink-c-upload-"+hex.EncodeToString(hash[:16])+".json")
+}
+
+// journalScope identifies access in journal paths without storing it.
+func journalScope(access *uplink.Access) string {
+	serialized, err := access.Serialize()
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(serialized))
+	return hex.EncodeToString(hash[:])
+}
+
+// journalPath returns where the journal for the upload to bucket and key in proj is stored.
+func (proj *Project) journalPath(bucket, key string) (string, error) {
+	dir, err := journalDir(proj.tempDir)
+	if err != nil {
+		return "", err
+	}
+	return journalPath(dir, proj.journalScope, bucket, key), nil
+}
+
+// createJournal creates a new journal and stores it on disk.
+func createJournal(path string, state journalState) (*uploadJournal, error) {
+	journal := &uploadJournal{path: path, state: state}
+	if err := journal.save(); err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// loadJournal loads the journal stored at path.
+func loadJournal(path string) (*uploadJournal, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &uploadJournal{path: path}
+	if err := json.Unmarshal(data, &journal.state); err != nil {
+		return nil, errs.New("invalid journal %q: %v", path, err)
+	}
+	return journal, nil
+}
+
+// Complete records that the part with index has been committed.
+func (journal *uploadJournal) Complete(index int) error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	journal.state.Completed = append(journal.state.Completed, index)
+	sort.Ints(journal.state.Completed)
+	return journal.save()
+}
+
+// Confirmed returns how many parts from the start are recorded as completed
+// and also satisfy exists.
+func (journal *uploadJournal) Confirmed(exists func(index int) bool) int {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	confirmed := 0
+	for _, index := range journal.state.Completed {
+		if index != confirmed || !exists(index) {
+			break
+		}
+		confirmed++
+	}
+	return confirmed
+}
+
+// Reset keeps only the first confirmed parts as completed.
+func (journal *uploadJournal) Reset(confirmed int) error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	completed := journal.state.Completed[:0]
+	for _, index := range journal.state.Completed {
+		if index < confirmed {
+			completed = append(completed, index)
+		}
+	}
+	journal.state.Completed = completed
+	return journal.save()
+}
+
+// Remove deletes the journal from disk.
+func (journal *uploadJournal) Remove() error {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	err := os.Remove(journal.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return errs.Wrap(err)
+}
+
+// save atomically writes the journal to disk, journal.mu must be held.
+func (journal *uploadJournal) save() error {
+	data, err := json.Marshal(journal.state)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+
+	tmp := journal.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errs.Wrap(err)
+	}
+	return errs.Wrap(os.Rename(tmp, journal.path))
+}
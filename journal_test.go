@@ -0,0 +1,52 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uplink-c")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := journalPath(dir, "scope", "bucket", "key")
+	require.NotEqual(t, path, journalPath(dir, "scope", "bucket", "other"))
+	require.NotEqual(t, path, journalPath(dir, "other", "bucket", "key"))
+
+	journal, err := createJournal(path, journalState{
+		Bucket:   "bucket",
+		Key:      "key",
+		UploadID: "id",
+		PartSize: 10,
+	})
+	require.NoError(t, err)
+
+	// parts may finish out of order
+	require.NoError(t, journal.Complete(1))
+	require.NoError(t, journal.Complete(0))
+	require.NoError(t, journal.Complete(3))
+
+	loaded, err := loadJournal(path)
+	require.NoError(t, err)
+	require.Equal(t, "id", loaded.state.UploadID)
+	require.Equal(t, []int{0, 1, 3}, loaded.state.Completed)
+
+	all := func(int) bool { return true }
+	require.Equal(t, 2, loaded.Confirmed(all))
+	require.Equal(t, 1, loaded.Confirmed(func(index int) bool { return index != 1 }))
+
+	require.NoError(t, loaded.Reset(2))
+	require.Equal(t, []int{0, 1}, loaded.state.Completed)
+
+	require.NoError(t, loaded.Remove())
+	_, err = loadJournal(path)
+	require.True(t, os.IsNotExist(err))
+	require.NoError(t, loaded.Remove())
+}
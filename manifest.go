@@ -0,0 +1,801 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zeebo/errs"
+
+	"storj.io/uplink"
+)
+
+// manifestMetadataKey marks objects whose data is a manifest of parts.
+const manifestMetadataKey = "uplink-c:manifest"
+
+// manifestSizeMetadataKey holds the size of a manifest object before it was split into parts.
+const manifestSizeMetadataKey = "uplink-c:manifest-size"
+
+// manifestVersion is the current version of the manifest format.
+const manifestVersion = 1
+
+// maxManifestSize limits how much is read when loading a manifest.
+const maxManifestSize = 64 << 20
+
+// manifest describes an object, whose data is stored in multiple part objects.
+type manifest struct {
+	Version  int            `json:"version"`
+	UploadID string         `json:"upload_id"`
+	Size     int64          `json:"size"`
+	PartSize int64          `json:"part_size"`
+	Parts    []manifestPart `json:"parts"`
+}
+
+// manifestPart is a single part object of a manifest.
+type manifestPart struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// isManifest returns whether the object data is a manifest of parts.
+func isManifest(object *uplink.Object) bool {
+	if object == nil {
+		return false
+	}
+	_, ok := object.Custom[manifestMetadataKey]
+	return ok
+}
+
+// partKey returns the key where part index of an upload is stored.
+func partKey(key, uploadID string, index int) string {
+	return fmt.Sprintf("%s.parts/%s/%08d", key, uploadID, index)
+}
+
+// isPartOf returns whether key is a part of the manifest object at manifestKey.
+func isPartOf(key, manifestKey string) bool {
+	return strings.HasPrefix(key, manifestKey+".parts/")
+}
+
+// isPartKey returns whether key is where a part of an upload is stored.
+func isPartKey(key string) bool {
+	i := strings.LastIndex(key, ".parts/")
+	if i < 0 {
+		return false
+	}
+	rest := strings.Split(key[i+len(".parts/"):], "/")
+	return len(rest) == 2 && len(rest[0]) == 32 && len(rest[1]) == 8
+}
+
+// deleteKeyWithParts deletes the object at key and, when it is a manifest, its parts.
+func deleteKeyWithParts(ctx context.Context, project *uplink.Project, bucket, key string) (*uplink.Object, error) {
+	object, err := project.StatObject(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return deleteObjectWithParts(ctx, project, bucket, object)
+}
+
+// deleteObjectWithParts deletes the object at key and, when it is a manifest,
+// its parts. Parts are deleted on a best-effort basis after the manifest.
+// The object is returned as it was uploaded before it was split into parts.
+func deleteObjectWithParts(ctx context.Context, project *uplink.Project, bucket string, object *uplink.Object) (*uplink.Object, error) {
+	var m *manifest
+	if isManifest(object) {
+		var err error
+		m, err = readManifest(ctx, project, bucket, object.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deleted, err := project.DeleteObject(ctx, bucket, object.Key)
+	if err != nil || m == nil {
+		return deleted, err
+	}
+	for _, part := range m.Parts {
+		_, _ = project.DeleteObject(ctx, bucket, part.Key)
+	}
+	return manifestObject(deleted, m), nil
+}
+
+// replacedManifest returns the manifest of the object at key, which is about to be
+// replaced. It is nil when there is no such object or it is not uploaded in parts.
+func replacedManifest(ctx context.Context, project *uplink.Project, bucket, key string) *manifest {
+	object, err := project.StatObject(ctx, bucket, key)
+	if err != nil || !isManifest(object) {
+		return nil
+	}
+	m, err := readManifest(ctx, project, bucket, key)
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// commitReplacing runs commit for an upload to key and afterwards deletes the parts
+// of the object uploaded in parts it replaced, unless they belong to uploadID.
+// Otherwise the parts would be kept, while they are hidden from listings.
+func commitReplacing(ctx context.Context, project *uplink.Project, bucket, key, uploadID string, commit func() error) error {
+	replaced := replacedManifest(ctx, project, bucket, key)
+	if err := commit(); err != nil {
+		return err
+	}
+	if replaced == nil || replaced.UploadID == uploadID {
+		return nil
+	}
+	for _, part := range replaced.Parts {
+		_, _ = project.DeleteObject(ctx, bucket, part.Key)
+	}
+	return nil
+}
+
+// replacingUpload is an upload of a single object, which deletes the parts of
+// the object uploaded in parts it replaces on commit.
+type replacingUpload struct {
+	*uplink.Upload
+	ctx     context.Context
+	project *uplink.Project
+	bucket  string
+	key     string
+}
+
+// Commit commits the object and deletes the parts of the replaced object.
+func (upload *replacingUpload) Commit() error {
+	return commitReplacing(upload.ctx, upload.project, upload.bucket, upload.key, "", upload.Upload.Commit)
+}
+
+// newUploadID returns a random identifier for an upload.
+func newUploadID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", errs.Wrap(err)
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// readManifest downloads and parses the manifest stored at key.
+func readManifest(ctx context.Context, project *uplink.Project, bucket, key string) (*manifest, error) {
+	download, err := project.DownloadObject(ctx, bucket, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = download.Close() }()
+
+	data, err := ioutil.ReadAll(io.LimitReader(download, maxManifestSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errs.New("invalid manifest %q: %v", key, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, errs.New("unsupported manifest version %d for %q", m.Version, key)
+	}
+	return &m, nil
+}
+
+// manifestObject returns the object as it was uploaded before it was split into parts.
+func manifestObject(object *uplink.Object, m *manifest) *uplink.Object {
+	logical := *object
+	logical.System.ContentLength = m.Size
+	logical.Custom = uplink.CustomMetadata{}
+	for k, v := range object.Custom {
+		if k != manifestMetadataKey && k != manifestSizeMetadataKey {
+			logical.Custom[k] = v
+		}
+	}
+	return &logical
+}
+
+// publicObject returns object as it is reported by stat and list calls. The reserved
+// custom metadata is removed and, when withSize is set, objects uploaded in parts have
+// the size they were uploaded with.
+func publicObject(ctx context.Context, project *uplink.Project, bucket string, object *uplink.Object, withSize bool) (*uplink.Object, error) {
+	if object == nil {
+		return nil, nil
+	}
+
+	public := *object
+	if withSize && isManifest(object) {
+		size, err := manifestSize(ctx, project, bucket, object)
+		if err != nil {
+			return nil, err
+		}
+		public.System.ContentLength = size
+	}
+
+	if object.Custom != nil {
+		public.Custom = uplink.CustomMetadata{}
+		for k, v := range object.Custom {
+			if !strings.HasPrefix(k, reservedMetadataPrefix) {
+				public.Custom[k] = v
+			}
+		}
+	}
+	return &public, nil
+}
+
+// statObject returns the information about the object at key as reported to callers.
+func statObject(ctx context.Context, project *uplink.Project, bucket, key string) (*uplink.Object, error) {
+	object, err := project.StatObject(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	return publicObject(ctx, project, bucket, object, true)
+}
+
+// manifestSize returns the size of the manifest object before it was split into parts.
+// Manifests committed without the size in their metadata are downloaded.
+func manifestSize(ctx context.Context, project *uplink.Project, bucket string, object *uplink.Object) (int64, error) {
+	if size, err := strconv.ParseInt(object.Custom[manifestSizeMetadataKey], 10, 64); err == nil {
+		return size, nil
+	}
+
+	m, err := readManifest(ctx, project, bucket, object.Key)
+	if err != nil {
+		return 0, err
+	}
+	return m.Size, nil
+}
+
+// resolveManifest loads the manifest of object and returns the segments to download
+// for opts, each of them at most partSize bytes. When partSize is 0, the segments
+// are not split further.
+func resolveManifest(ctx context.Context, project *uplink.Project, bucket string, object *uplink.Object, opts *uplink.DownloadOptions, partSize int64) (*uplink.Object, []segment, error) {
+	m, err := readManifest(ctx, project, bucket, object.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return manifestObject(object, m), manifestSegments(m, opts, partSize), nil
+}
+
+// manifestSegments returns the segments of the parts covering the range in opts.
+func manifestSegments(m *manifest, opts *uplink.DownloadOptions, partSize int64) []segment {
+	start := opts.Offset
+	end := start + downloadLength(m.Size, opts)
+
+	var segments []segment
+	var position int64
+	for _, part := range m.Parts {
+		partStart, partEnd := position, position+part.Size
+		position = partEnd
+
+		if partEnd <= start || partStart >= end {
+			continue
+		}
+
+		from, to := partStart, partEnd
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+
+		size := partSize
+		if size <= 0 {
+			size = to - from
+		}
+		for _, r := range splitRange(from-partStart, to-from, size) {
+			segments = append(segments, segment{key: part.Key, byteRange: r})
+		}
+	}
+	return segments
+}
+
+// sequentialDownload downloads segments one after another.
+type sequentialDownload struct {
+	ctx      context.Context
+	project  *uplink.Project
+	bucket   string
+	object   *uplink.Object
+	segments []segment
+	retry    retryPolicy
+
+	current objectDownload
+	read    int64
+}
+
+func newSequentialDownload(ctx context.Context, project *uplink.Project, bucket string, object *uplink.Object, segments []segment, retry retryPolicy) *sequentialDownload {
+	return &sequentialDownload{
+		ctx:      ctx,
+		project:  project,
+		bucket:   bucket,
+		object:   object,
+		segments: segments,
+		retry:    retry,
+	}
+}
+
+// Info returns the information about the object when the download started.
+func (download *sequentialDownload) Info() *uplink.Object {
+	return download.object
+}
+
+// Read reads the segments in order.
+func (download *sequentialDownload) Read(p []byte) (int, error) {
+	for {
+		if download.current == nil {
+			if len(download.segments) == 0 {
+				return 0, io.EOF
+			}
+
+			seg := download.segments[0]
+			current, err := openRetryingDownload(download.ctx, download.project, download.bucket, seg.key, &uplink.DownloadOptions{
+				Offset: seg.offset,
+				Length: seg.length,
+			}, download.retry)
+			if err != nil {
+				return 0, err
+			}
+			download.current, download.read = current, 0
+		}
+
+		n, err := download.current.Read(p)
+		download.read += int64(n)
+		if errors.Is(err, io.EOF) {
+			_ = download.current.Close()
+			download.current = nil
+
+			if download.read != download.segments[0].length {
+				return n, ErrObjectChanged.New("%q is shorter than expected", download.segments[0].key)
+			}
+			download.segments = download.segments[1:]
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// Close closes the current segment download.
+func (download *sequentialDownload) Close() error {
+	if download.current == nil {
+		return nil
+	}
+	err := download.current.Close()
+	download.current = nil
+	return err
+}
+
+// chunkedUpload splits the data into parts, which are uploaded concurrently
+// as separate objects, and commits a manifest of the parts at the key.
+type chunkedUpload struct {
+	ctx     context.Context
+	cancel  func()
+	project *uplink.Project
+	bucket  string
+	key     string
+	opts    *uplink.UploadOptions
+	policy  parallelPolicy
+
+	uploadID string
+	buffer   []byte
+	limiter  chan struct{}
+	wg       sync.WaitGroup
+
+	// journal records the completed parts of a resumable upload.
+	journal *uploadJournal
+
+	mu     sync.Mutex
+	parts  []manifestPart
+	size   int64
+	err    error
+	custom uplink.CustomMetadata
+	done   bool
+	object *uplink.Object
+}
+
+// newChunkedUpload starts an upload split into parts according to policy.
+func newChunkedUpload(ctx context.Context, project *uplink.Project, bucket, key string, opts *uplink.UploadOptions, policy parallelPolicy) (*chunkedUpload, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("%w (%q)", uplink.ErrBucketNameInvalid, bucket)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%w (%q)", uplink.ErrObjectKeyInvalid, key)
+	}
+
+	uploadID, err := newUploadID()
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := policy.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &chunkedUpload{
+		ctx:      ctx,
+		cancel:   cancel,
+		project:  project,
+		bucket:   bucket,
+		key:      key,
+		opts:     opts,
+		policy:   policy,
+		uploadID: uploadID,
+		limiter:  make(chan struct{}, concurrency),
+		object:   &uplink.Object{Key: key},
+	}, nil
+}
+
+// Info returns the last information about the uploaded object.
+func (upload *chunkedUpload) Info() *uplink.Object {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	return upload.object
+}
+
+// Write buffers p and starts uploading every full part.
+func (upload *chunkedUpload) Write(p []byte) (int, error) {
+	if err := upload.check(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		free := int(upload.policy.partSize) - len(upload.buffer)
+		if free > len(p) {
+			free = len(p)
+		}
+		upload.buffer = append(upload.buffer, p[:free]...)
+		p = p[free:]
+		written += free
+
+		if int64(len(upload.buffer)) >= upload.policy.partSize {
+			if err := upload.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// check returns the error that stopped the upload.
+func (upload *chunkedUpload) check() error {
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	if upload.done {
+		return fmt.Errorf("%w: already committed or aborted", uplink.ErrUploadDone)
+	}
+	return upload.err
+}
+
+// flush starts uploading the buffered data as the next part.
+func (upload *chunkedUpload) flush() error {
+	select {
+	case upload.limiter <- struct{}{}:
+	case <-upload.ctx.Done():
+		return upload.ctx.Err()
+	}
+
+	data := upload.buffer
+	upload.buffer = nil
+
+	upload.mu.Lock()
+	index := len(upload.parts)
+	part := manifestPart{
+		Key:  partKey(upload.key, upload.uploadID, index),
+		Size: int64(len(data)),
+	}
+	upload.parts = append(upload.parts, part)
+	upload.size += part.Size
+	upload.mu.Unlock()
+
+	upload.wg.Add(1)
+	go func() {
+		defer upload.wg.Done()
+		defer func() { <-upload.limiter }()
+
+		err := upload.uploadPart(part.Key, data)
+		if err == nil && upload.journal != nil {
+			err = upload.journal.Complete(index)
+		}
+		if err != nil {
+			upload.mu.Lock()
+			if upload.err == nil {
+				upload.err = err
+			}
+			upload.mu.Unlock()
+			upload.cancel()
+		}
+	}()
+
+	return nil
+}
+
+// uploadPart uploads data as a separate object at key.
+func (upload *chunkedUpload) uploadPart(key string, data []byte) error {
+	part, err := upload.project.UploadObject(upload.ctx, upload.bucket, key, upload.opts)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		_ = part.Abort()
+		return err
+	}
+	return part.Commit()
+}
+
+// SetCustomMetadata sets custom metadata to be included with the manifest.
+func (upload *chunkedUpload) SetCustomMetadata(ctx context.Context, custom uplink.CustomMetadata) error {
+	if err := upload.check(); err != nil {
+		return err
+	}
+	if custom == nil {
+		return nil
+	}
+	if err := custom.Verify(); err != nil {
+		return err
+	}
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+	upload.custom = custom.Clone()
+	return nil
+}
+
+// Commit waits for all parts to be uploaded and commits the manifest.
+func (upload *chunkedUpload) Commit() (err error) {
+	if err := upload.check(); err != nil {
+		return err
+	}
+
+	if len(upload.buffer) > 0 || len(upload.parts) == 0 {
+		if err := upload.flush(); err != nil {
+			upload.wg.Wait()
+			return upload.fail(err)
+		}
+	}
+	upload.wg.Wait()
+
+	upload.mu.Lock()
+	err = upload.err
+	m := manifest{
+		Version:  manifestVersion,
+		UploadID: upload.uploadID,
+		Size:     upload.size,
+		PartSize: upload.policy.partSize,
+		Parts:    upload.parts,
+	}
+	custom := upload.custom.Clone()
+	upload.mu.Unlock()
+	if err != nil {
+		return upload.fail(err)
+	}
+
+	object, err := commitManifest(upload.ctx, upload.project, upload.bucket, upload.key, upload.opts, &m, custom)
+	if err != nil {
+		return upload.fail(err)
+	}
+
+	upload.mu.Lock()
+	upload.done = true
+	upload.object = object
+	upload.mu.Unlock()
+
+	upload.cancel()
+	if upload.journal != nil {
+		_ = upload.journal.Remove()
+	}
+	return nil
+}
+
+// commitManifest uploads m to key and returns the object as it was uploaded before it was split.
+func commitManifest(ctx context.Context, project *uplink.Project, bucket, key string, opts *uplink.UploadOptions, m *manifest, custom uplink.CustomMetadata) (*uplink.Object, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, errs.Wrap(err)
+	}
+
+	if custom == nil {
+		custom = uplink.CustomMetadata{}
+	}
+	custom[manifestMetadataKey] = fmt.Sprint(manifestVersion)
+	custom[manifestSizeMetadataKey] = strconv.FormatInt(m.Size, 10)
+
+	upload, err := project.UploadObject(ctx, bucket, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := upload.SetCustomMetadata(ctx, custom); err != nil {
+		_ = upload.Abort()
+		return nil, err
+	}
+	if _, err := upload.Write(data); err != nil {
+		_ = upload.Abort()
+		return nil, err
+	}
+	if err := commitReplacing(ctx, project, bucket, key, m.UploadID, upload.Commit); err != nil {
+		return nil, err
+	}
+
+	return manifestObject(upload.Info(), m), nil
+}
+
+// Abort stops the upload and removes the already uploaded parts,
+// including the ones kept for resuming.
+func (upload *chunkedUpload) Abort() error {
+	upload.mu.Lock()
+	done := upload.done
+	upload.done = true
+	upload.mu.Unlock()
+	if done {
+		return fmt.Errorf("%w: already committed or aborted", uplink.ErrUploadDone)
+	}
+
+	upload.cancel()
+	upload.wg.Wait()
+	upload.removeParts()
+	if upload.journal != nil {
+		_ = upload.journal.Remove()
+	}
+	return nil
+}
+
+// release stops an unfinished upload, the uploaded parts are kept
+// only when the upload is resumable.
+func (upload *chunkedUpload) release() {
+	upload.mu.Lock()
+	done := upload.done
+	upload.done = true
+	upload.mu.Unlock()
+	if done {
+		return
+	}
+
+	upload.cancel()
+	upload.wg.Wait()
+	_ = upload.fail(context.Canceled)
+}
+
+// fail marks the upload as done and removes the uploaded parts,
+// unless they are kept for resuming the upload.
+func (upload *chunkedUpload) fail(err error) error {
+	upload.mu.Lock()
+	upload.done = true
+	upload.mu.Unlock()
+
+	upload.cancel()
+	if upload.journal == nil {
+		upload.removeParts()
+	}
+	return err
+}
+
+// removeParts deletes the uploaded parts.
+func (upload *chunkedUpload) removeParts() {
+	upload.mu.Lock()
+	parts := upload.parts
+	upload.mu.Unlock()
+
+	// use a separate context, since the upload context may be already canceled.
+	ctx := context.Background()
+	for _, part := range parts {
+		_, _ = upload.project.DeleteObject(ctx, upload.bucket, part.Key)
+	}
+}
+
+// startResumableUpload starts a chunked upload, which records its progress in a journal.
+// The checksum flags are recorded, so that the checksums are also computed when it is resumed.
+//
+// An interrupted upload to the same key, whose journal is at path, cannot be resumed
+// afterwards, so its parts are deleted.
+func startResumableUpload(ctx context.Context, project *uplink.Project, path, bucket, key string, opts *uplink.UploadOptions, policy parallelPolicy, checksums uint32) (*chunkedUpload, error) {
+	if previous, err := loadJournal(path); err == nil {
+		if err := deleteUploadParts(ctx, project, bucket, key, previous.state.UploadID); err != nil {
+			return nil, err
+		}
+	}
+
+	upload, err := newChunkedUpload(ctx, project, bucket, key, opts, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.journal, err = createJournal(path, journalState{
+		Bucket:    bucket,
+		Key:       key,
+		UploadID:  upload.uploadID,
+		PartSize:  policy.partSize,
+		Expires:   opts.Expires,
+		Checksums: checksums,
+	})
+	if err != nil {
+		upload.cancel()
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// deleteUploadParts deletes the uploaded parts of the upload to key with uploadID.
+func deleteUploadParts(ctx context.Context, project *uplink.Project, bucket, key, uploadID string) error {
+	iterator := project.ListObjects(ctx, bucket, &uplink.ListObjectsOptions{
+		Prefix:    fmt.Sprintf("%s.parts/%s/", key, uploadID),
+		Recursive: true,
+	})
+	for iterator.Next() {
+		_, _ = project.DeleteObject(ctx, bucket, iterator.Item().Key)
+	}
+	return iterator.Err()
+}
+
+// resumeUpload continues an interrupted resumable upload to key. It returns the
+// offset in the data from which the upload must continue.
+func resumeUpload(ctx context.Context, project *uplink.Project, path, bucket, key string, policy parallelPolicy) (*chunkedUpload, int64, error) {
+	journal, err := loadJournal(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, fmt.Errorf("%w: no interrupted upload (%q)", uplink.ErrObjectNotFound, key)
+		}
+		return nil, 0, errs.Wrap(err)
+	}
+	state := journal.state
+
+	// inspect which parts have been actually committed
+	committed := map[string]int64{}
+	iterator := project.ListObjects(ctx, bucket, &uplink.ListObjectsOptions{
+		Prefix:    fmt.Sprintf("%s.parts/%s/", key, state.UploadID),
+		Recursive: true,
+		System:    true,
+	})
+	for iterator.Next() {
+		item := iterator.Item()
+		committed[item.Key] = item.System.ContentLength
+	}
+	if err := iterator.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	confirmed := journal.Confirmed(func(index int) bool {
+		size, ok := committed[partKey(key, state.UploadID, index)]
+		return ok && size == state.PartSize
+	})
+	if err := journal.Reset(confirmed); err != nil {
+		return nil, 0, err
+	}
+
+	policy.partSize = state.PartSize
+	upload, err := newChunkedUpload(ctx, project, bucket, key, &uplink.UploadOptions{Expires: state.Expires}, policy)
+	if err != nil {
+		return nil, 0, err
+	}
+	upload.uploadID = state.UploadID
+	upload.journal = journal
+	for index := 0; index < confirmed; index++ {
+		part := manifestPart{
+			Key:  partKey(key, state.UploadID, index),
+			Size: state.PartSize,
+		}
+		upload.parts = append(upload.parts, part)
+		upload.size += part.Size
+		delete(committed, part.Key)
+	}
+
+	// remove the parts that are uploaded again
+	for stale := range committed {
+		_, _ = project.DeleteObject(ctx, bucket, stale)
+	}
+
+	return upload, upload.size, nil
+}
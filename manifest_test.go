@@ -0,0 +1,132 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestManifestSegments(t *testing.T) {
+	m := &manifest{
+		Version: manifestVersion,
+		Size:    25,
+		Parts: []manifestPart{
+			{Key: "a", Size: 10},
+			{Key: "b", Size: 10},
+			{Key: "c", Size: 5},
+		},
+	}
+
+	{ // everything
+		segments := manifestSegments(m, &uplink.DownloadOptions{Offset: 0, Length: -1}, 0)
+		require.Equal(t, []segment{
+			{key: "a", byteRange: byteRange{offset: 0, length: 10}},
+			{key: "b", byteRange: byteRange{offset: 0, length: 10}},
+			{key: "c", byteRange: byteRange{offset: 0, length: 5}},
+		}, segments)
+	}
+
+	{ // range across parts
+		segments := manifestSegments(m, &uplink.DownloadOptions{Offset: 5, Length: 12}, 0)
+		require.Equal(t, []segment{
+			{key: "a", byteRange: byteRange{offset: 5, length: 5}},
+			{key: "b", byteRange: byteRange{offset: 0, length: 7}},
+		}, segments)
+	}
+
+	{ // split into smaller segments
+		segments := manifestSegments(m, &uplink.DownloadOptions{Offset: 18, Length: -1}, 4)
+		require.Equal(t, []segment{
+			{key: "b", byteRange: byteRange{offset: 8, length: 2}},
+			{key: "c", byteRange: byteRange{offset: 0, length: 4}},
+			{key: "c", byteRange: byteRange{offset: 4, length: 1}},
+		}, segments)
+	}
+}
+
+func TestManifestObject(t *testing.T) {
+	object := &uplink.Object{
+		Key: "data",
+		System: uplink.SystemMetadata{
+			ContentLength: 100,
+		},
+		Custom: uplink.CustomMetadata{
+			manifestMetadataKey: "1",
+			"app:key":           "value",
+		},
+	}
+	require.True(t, isManifest(object))
+
+	logical := manifestObject(object, &manifest{Size: 12345})
+	require.False(t, isManifest(logical))
+	require.Equal(t, int64(12345), logical.System.ContentLength)
+	require.Equal(t, uplink.CustomMetadata{"app:key": "value"}, logical.Custom)
+	require.True(t, isManifest(object), "original object must not be modified")
+}
+
+func TestIsPartKey(t *testing.T) {
+	uploadID, err := newUploadID()
+	require.NoError(t, err)
+
+	key := partKey("dir/object", uploadID, 3)
+	require.True(t, isPartKey(key))
+	require.True(t, isPartOf(key, "dir/object"))
+
+	require.False(t, isPartKey("dir/object"))
+	require.False(t, isPartKey("dir/object.parts/"))
+	require.False(t, isPartKey("notes.parts/todo.txt"))
+}
+
+func TestPublicObject(t *testing.T) {
+	object := &uplink.Object{
+		Key: "object",
+		Custom: uplink.CustomMetadata{
+			"a":                               "1",
+			manifestMetadataKey:               "1",
+			manifestSizeMetadataKey:           "1000",
+			checksumMetadataPrefix + "sha256": "00",
+		},
+	}
+	object.System.ContentLength = 120
+
+	public, err := publicObject(context.Background(), nil, "bucket", object, true)
+	require.NoError(t, err)
+	require.EqualValues(t, 1000, public.System.ContentLength)
+	require.Equal(t, uplink.CustomMetadata{"a": "1"}, public.Custom)
+
+	// the listed object is not modified.
+	require.EqualValues(t, 120, object.System.ContentLength)
+	require.Len(t, object.Custom, 4)
+
+	public, err = publicObject(context.Background(), nil, "bucket", object, false)
+	require.NoError(t, err)
+	require.EqualValues(t, 120, public.System.ContentLength)
+}
+
+func TestHiddenObjects(t *testing.T) {
+	uploadID, err := newUploadID()
+	require.NoError(t, err)
+	tempKey, err := rewriteKey("dir/other")
+	require.NoError(t, err)
+
+	objects := &filteredObjects{manifests: map[string]struct{}{"dir/object": {}}}
+	for _, tt := range []struct {
+		object *uplink.Object
+		hidden bool
+	}{
+		{object: &uplink.Object{Key: "dir/object"}},
+		{object: &uplink.Object{Key: partKey("dir/object", uploadID, 0)}, hidden: true},
+		{object: &uplink.Object{Key: "dir/object.parts/", IsPrefix: true}, hidden: true},
+		{object: &uplink.Object{Key: "dir/notes.parts/", IsPrefix: true}},
+		{object: &uplink.Object{Key: tempKey}, hidden: true},
+		{object: &uplink.Object{Key: "dir/other" + rewriteKeyInfix, IsPrefix: true}, hidden: true},
+	} {
+		require.Equal(t, tt.hidden, objects.hidden(tt.object), tt.object.Key)
+	}
+}
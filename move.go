@@ -0,0 +1,246 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"strings"
+
+	"storj.io/uplink"
+)
+
+//export uplink_move_object
+// uplink_move_object moves the object at src_key to dst_key.
+//
+// The object is copied, the copy is compared with the source and then the source is
+// deleted. When the copy fails nothing is changed. When the verification or the
+// deletion fails, or the source is replaced during the move, both objects are kept
+// and UPLINK_ERROR_MOVE_INCOMPLETE is returned.
+func uplink_move_object(project *C.Uplink_Project, src_bucket, src_key, dst_bucket, dst_key *C.char) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_move_object_with_cancel(project, src_bucket, src_key, dst_bucket, dst_key, nil)
+}
+
+//export uplink_move_object_with_cancel
+// uplink_move_object_with_cancel moves the object at src_key to dst_key.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_move_object_with_cancel(project *C.Uplink_Project, src_bucket, src_key, dst_bucket, dst_key *C.char, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if src_bucket == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("src_bucket")),
+		}
+	}
+	if src_key == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("src_key")),
+		}
+	}
+	if dst_bucket == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("dst_bucket")),
+		}
+	}
+	if dst_key == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("dst_key")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	object, err := moveObject(scope.ctx, proj, C.GoString(src_bucket), C.GoString(src_key), C.GoString(dst_bucket), C.GoString(dst_key))
+	return C.Uplink_ObjectResult{
+		error:  mallocError(err),
+		object: mallocObject(object),
+	}
+}
+
+//export uplink_move_prefix
+// uplink_move_prefix moves every object under src_prefix to the same key under dst_prefix.
+//
+// Objects are moved one at a time as with uplink_move_object, it stops at the first failure
+// and returns the number of objects moved until then.
+func uplink_move_prefix(project *C.Uplink_Project, src_bucket, src_prefix, dst_bucket, dst_prefix *C.char) C.Uplink_MovePrefixResult { //nolint:golint
+	return uplink_move_prefix_with_cancel(project, src_bucket, src_prefix, dst_bucket, dst_prefix, nil)
+}
+
+//export uplink_move_prefix_with_cancel
+// uplink_move_prefix_with_cancel moves every object under src_prefix to the same key under dst_prefix.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_move_prefix_with_cancel(project *C.Uplink_Project, src_bucket, src_prefix, dst_bucket, dst_prefix *C.char, token *C.Uplink_CancelToken) C.Uplink_MovePrefixResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if src_bucket == nil {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(ErrNull.New("src_bucket")),
+		}
+	}
+	if src_prefix == nil {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(ErrNull.New("src_prefix")),
+		}
+	}
+	if dst_bucket == nil {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(ErrNull.New("dst_bucket")),
+		}
+	}
+	if dst_prefix == nil {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(ErrNull.New("dst_prefix")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_MovePrefixResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	moved, err := movePrefix(scope.ctx, proj, C.GoString(src_bucket), C.GoString(src_prefix), C.GoString(dst_bucket), C.GoString(dst_prefix))
+	return C.Uplink_MovePrefixResult{
+		moved: C.int64_t(moved),
+		error: mallocError(err),
+	}
+}
+
+//export uplink_free_move_prefix_result
+// uplink_free_move_prefix_result frees any resources associated with move prefix result.
+func uplink_free_move_prefix_result(result C.Uplink_MovePrefixResult) {
+	uplink_free_error(result.error)
+}
+
+// moveObject copies the source object, verifies the copy and deletes the source.
+func moveObject(ctx context.Context, proj *Project, srcBucket, srcKey, dstBucket, dstKey string) (*uplink.Object, error) {
+	if srcBucket == dstBucket && srcKey == dstKey {
+		return nil, ErrInvalidArg.New("source and destination are the same object")
+	}
+
+	stored, err := proj.StatObject(ctx, srcBucket, srcKey)
+	if err != nil {
+		return nil, err
+	}
+
+	source, _, err := copyObject(ctx, proj, srcBucket, srcKey, proj, dstBucket, dstKey, copyOverride{keepExpires: true})
+	if err != nil {
+		return nil, err
+	}
+	if !source.System.Created.Equal(stored.System.Created) {
+		return nil, ErrMoveIncomplete.New("%q was replaced while it was copied", srcKey)
+	}
+
+	copied, err := proj.StatObject(ctx, dstBucket, dstKey)
+	if err != nil {
+		return nil, ErrMoveIncomplete.New("verifying %q: %v", dstKey, err)
+	}
+	if err := verifyCopy(source, copied); err != nil {
+		return nil, err
+	}
+
+	// the source is deleted by key, so it must still be the object that was copied.
+	current, err := proj.StatObject(ctx, srcBucket, srcKey)
+	if err != nil {
+		return nil, ErrMoveIncomplete.New("verifying %q: %v", srcKey, err)
+	}
+	if !sameObject(current, stored) {
+		return nil, ErrMoveIncomplete.New("%q was replaced while it was moved", srcKey)
+	}
+
+	if _, err := deleteObjectWithParts(ctx, proj.Project, srcBucket, stored); err != nil {
+		return nil, ErrMoveIncomplete.New("deleting %q: %v", srcKey, err)
+	}
+	return publicObject(ctx, proj.Project, dstBucket, copied, false)
+}
+
+// verifyCopy checks whether copied has the same size and custom metadata as source.
+func verifyCopy(source, copied *uplink.Object) error {
+	if copied.System.ContentLength != source.System.ContentLength {
+		return ErrMoveIncomplete.New("copy of %q has %d bytes instead of %d", source.Key, copied.System.ContentLength, source.System.ContentLength)
+	}
+	if len(copied.Custom) != len(source.Custom) {
+		return ErrMoveIncomplete.New("copy of %q has different custom metadata", source.Key)
+	}
+	for k, v := range source.Custom {
+		if value, ok := copied.Custom[k]; !ok || value != v {
+			return ErrMoveIncomplete.New("copy of %q has different custom metadata", source.Key)
+		}
+	}
+	return nil
+}
+
+// movePrefix moves all objects under srcPrefix to dstPrefix and returns how many were moved.
+// The parts of objects uploaded in parts are moved together with their manifest.
+func movePrefix(ctx context.Context, proj *Project, srcBucket, srcPrefix, dstBucket, dstPrefix string) (moved int64, err error) {
+	if srcBucket == dstBucket && srcPrefix == dstPrefix {
+		return 0, ErrInvalidArg.New("source and destination are the same prefix")
+	}
+
+	// collect the keys first, so the moved objects are not listed again when
+	// the destination is under the source prefix.
+	var keys, manifests []string
+	iterator := proj.ListObjects(ctx, srcBucket, &uplink.ListObjectsOptions{
+		Prefix:    srcPrefix,
+		Recursive: true,
+		Custom:    true,
+	})
+	for iterator.Next() {
+		object := iterator.Item()
+		if isManifest(object) {
+			manifests = append(manifests, object.Key)
+		}
+		keys = append(keys, object.Key)
+	}
+	if err := iterator.Err(); err != nil {
+		return 0, err
+	}
+
+next:
+	for _, key := range keys {
+		for _, manifestKey := range manifests {
+			if isPartOf(key, manifestKey) {
+				continue next
+			}
+		}
+
+		dstKey := dstPrefix + strings.TrimPrefix(key, srcPrefix)
+		if _, err := moveObject(ctx, proj, srcBucket, key, dstBucket, dstKey); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
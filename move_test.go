@@ -0,0 +1,34 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestVerifyCopy(t *testing.T) {
+	source := &uplink.Object{Key: "a", Custom: uplink.CustomMetadata{"k": "v"}}
+	source.System.ContentLength = 10
+
+	copied := &uplink.Object{Key: "b", Custom: uplink.CustomMetadata{"k": "v"}}
+	copied.System.ContentLength = 10
+	require.NoError(t, verifyCopy(source, copied))
+
+	copied.Custom["k"] = "w"
+	require.True(t, ErrMoveIncomplete.Has(verifyCopy(source, copied)))
+
+	copied.Custom["k"] = "v"
+	copied.System.ContentLength = 9
+	require.True(t, ErrMoveIncomplete.Has(verifyCopy(source, copied)))
+}
+
+func TestIsPartOf(t *testing.T) {
+	require.True(t, isPartOf(partKey("dir/a", "id", 3), "dir/a"))
+	require.False(t, isPartOf("dir/a", "dir/a"))
+	require.False(t, isPartOf(partKey("dir/ab", "id", 3), "dir/a"))
+}
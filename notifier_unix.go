@@ -0,0 +1,62 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"syscall"
+
+	"github.com/zeebo/errs"
+)
+
+// notifier signals readiness through the read end of a non-blocking pipe.
+type notifier struct {
+	read, write int
+}
+
+// newNotifier creates a notifier backed by a pipe.
+func newNotifier() (*notifier, error) {
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		return nil, errs.Wrap(err)
+	}
+
+	for _, fd := range fds {
+		syscall.CloseOnExec(fd)
+		if err := syscall.SetNonblock(fd, true); err != nil {
+			_ = syscall.Close(fds[0])
+			_ = syscall.Close(fds[1])
+			return nil, errs.Wrap(err)
+		}
+	}
+
+	return &notifier{read: fds[0], write: fds[1]}, nil
+}
+
+// fd returns the pollable file descriptor.
+func (n *notifier) fd() int { return n.read }
+
+// signal makes the file descriptor readable.
+func (n *notifier) signal() {
+	_, _ = syscall.Write(n.write, []byte{1})
+}
+
+// clear makes the file descriptor not readable.
+func (n *notifier) clear() {
+	var buf [64]byte
+	for {
+		k, err := syscall.Read(n.read, buf[:])
+		if k <= 0 || err != nil {
+			return
+		}
+	}
+}
+
+// close releases the file descriptors.
+func (n *notifier) close() {
+	_ = syscall.Close(n.read)
+	_ = syscall.Close(n.write)
+}
@@ -0,0 +1,22 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+//go:build windows
+// +build windows
+
+package main
+
+import "github.com/zeebo/errs"
+
+// notifier is not available on windows, since there are no pollable pipes.
+type notifier struct{}
+
+// newNotifier always fails on windows.
+func newNotifier() (*notifier, error) {
+	return nil, errs.New("completion queue is not supported on windows")
+}
+
+func (n *notifier) fd() int { return -1 }
+func (n *notifier) signal() {}
+func (n *notifier) clear()  {}
+func (n *notifier) close()  {}
@@ -14,6 +14,14 @@ import (
 //export uplink_stat_object
 // uplink_stat_object returns information about an object at the specific key.
 func uplink_stat_object(project *C.Uplink_Project, bucket_name, object_key *C.char) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_stat_object_with_cancel(project, bucket_name, object_key, nil)
+}
+
+//export uplink_stat_object_with_cancel
+// uplink_stat_object_with_cancel returns information about an object at the specific key.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_stat_object_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_ObjectResult{
 			error: mallocError(ErrNull.New("project")),
@@ -37,7 +45,15 @@ func uplink_stat_object(project *C.Uplink_Project, bucket_name, object_key *C.ch
 		}
 	}
 
-	object, err := proj.StatObject(proj.scope.ctx, C.GoString(bucket_name), C.GoString(object_key))
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	object, err := statObject(scope.ctx, proj.Project, C.GoString(bucket_name), C.GoString(object_key))
 	return C.Uplink_ObjectResult{
 		error:  mallocError(err),
 		object: mallocObject(object),
@@ -47,6 +63,14 @@ func uplink_stat_object(project *C.Uplink_Project, bucket_name, object_key *C.ch
 //export uplink_delete_object
 // uplink_delete_object deletes an object.
 func uplink_delete_object(project *C.Uplink_Project, bucket_name, object_key *C.char) C.Uplink_ObjectResult { //nolint:golint
+	return uplink_delete_object_with_cancel(project, bucket_name, object_key, nil)
+}
+
+//export uplink_delete_object_with_cancel
+// uplink_delete_object_with_cancel deletes an object.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_delete_object_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, token *C.Uplink_CancelToken) C.Uplink_ObjectResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_ObjectResult{
 			error: mallocError(ErrNull.New("project")),
@@ -70,7 +94,15 @@ func uplink_delete_object(project *C.Uplink_Project, bucket_name, object_key *C.
 		}
 	}
 
-	deleted, err := proj.DeleteObject(proj.scope.ctx, C.GoString(bucket_name), C.GoString(object_key))
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	deleted, err := deleteKeyWithParts(scope.ctx, proj.Project, C.GoString(bucket_name), C.GoString(object_key))
 	return C.Uplink_ObjectResult{
 		error:  mallocError(err),
 		object: mallocObject(deleted),
@@ -0,0 +1,215 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"storj.io/uplink"
+)
+
+// reservedMetadataPrefix is the prefix of custom metadata keys used by the library.
+const reservedMetadataPrefix = "uplink-c:"
+
+// rewriteKeyInfix separates the key from the id of the temporary copy made while rewriting it.
+const rewriteKeyInfix = ".uplink-c-rewrite/"
+
+//export uplink_update_object_metadata
+// uplink_update_object_metadata changes the custom metadata of a committed object.
+//
+// By default custom replaces the metadata, with options merge it is added to the
+// existing metadata. Reserved keys, which start with "uplink-c:", are always kept.
+// The result reports whether the object was updated in place or rewritten.
+func uplink_update_object_metadata(project *C.Uplink_Project, bucket_name, object_key *C.char, custom C.Uplink_CustomMetadata, options *C.Uplink_UpdateObjectMetadataOptions) C.Uplink_UpdateObjectMetadataResult { //nolint:golint
+	return uplink_update_object_metadata_with_cancel(project, bucket_name, object_key, custom, options, nil)
+}
+
+//export uplink_update_object_metadata_with_cancel
+// uplink_update_object_metadata_with_cancel changes the custom metadata of a committed object.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_update_object_metadata_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, custom C.Uplink_CustomMetadata, options *C.Uplink_UpdateObjectMetadataOptions, token *C.Uplink_CancelToken) C.Uplink_UpdateObjectMetadataResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_UpdateObjectMetadataResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_UpdateObjectMetadataResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+	if object_key == nil {
+		return C.Uplink_UpdateObjectMetadataResult{
+			error: mallocError(ErrNull.New("object_key")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_UpdateObjectMetadataResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_UpdateObjectMetadataResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	merge := options != nil && bool(options.merge)
+	object, err := rewriteObjectMetadata(scope.ctx, proj, C.GoString(bucket_name), C.GoString(object_key), customMetadataFromC(custom), merge)
+	if err != nil {
+		return C.Uplink_UpdateObjectMetadataResult{
+			error: mallocError(err),
+		}
+	}
+
+	// the metadata of committed objects cannot be changed in place yet.
+	return C.Uplink_UpdateObjectMetadataResult{
+		object: mallocObject(object),
+		method: C.UPLINK_METADATA_UPDATE_REWRITE,
+	}
+}
+
+//export uplink_free_update_object_metadata_result
+// uplink_free_update_object_metadata_result frees any resources associated with the result.
+func uplink_free_update_object_metadata_result(result C.Uplink_UpdateObjectMetadataResult) {
+	uplink_free_error(result.error)
+	uplink_free_object(result.object)
+}
+
+// updatedMetadata returns the custom metadata after applying update to current.
+func updatedMetadata(current, update uplink.CustomMetadata, merge bool) uplink.CustomMetadata {
+	updated := uplink.CustomMetadata{}
+	for k, v := range current {
+		if k == manifestMetadataKey || k == manifestSizeMetadataKey {
+			continue
+		}
+		if merge || strings.HasPrefix(k, reservedMetadataPrefix) {
+			updated[k] = v
+		}
+	}
+	for k, v := range update {
+		if !strings.HasPrefix(k, reservedMetadataPrefix) {
+			updated[k] = v
+		}
+	}
+	return updated
+}
+
+// rewriteObjectMetadata changes the custom metadata by streaming the object into
+// a new object at the same key, since the metadata cannot be changed in place.
+//
+// The object is first copied to a temporary key and verified, so that the stored
+// object is only replaced by a complete copy. Objects uploaded in parts become a
+// single object and their parts are deleted once the object has been replaced.
+func rewriteObjectMetadata(ctx context.Context, proj *Project, bucket, key string, update uplink.CustomMetadata, merge bool) (*uplink.Object, error) {
+	stored, err := proj.StatObject(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := update.Verify(); err != nil {
+		return nil, err
+	}
+
+	var m *manifest
+	if isManifest(stored) {
+		m, err = readManifest(ctx, proj.Project, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		stored = manifestObject(stored, m)
+	}
+
+	tempKey, err := rewriteKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	_, temp, err := copyObject(ctx, proj, bucket, key, proj, bucket, tempKey, copyOverride{
+		keepExpires: true,
+		custom:      updatedMetadata(stored.Custom, update, merge),
+		verify:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyRewrite(ctx, proj, bucket, stored, temp); err != nil {
+		_, _ = proj.DeleteObject(ctx, bucket, tempKey)
+		return nil, err
+	}
+
+	// the object must not be replaced by a concurrent upload in the meantime.
+	current, err := proj.StatObject(ctx, bucket, key)
+	if err == nil && !current.System.Created.Equal(stored.System.Created) {
+		err = ErrObjectChanged.New("%q was replaced while its metadata was updated", key)
+	}
+	if err != nil {
+		_, _ = proj.DeleteObject(ctx, bucket, tempKey)
+		return nil, err
+	}
+
+	_, object, err := copyObject(ctx, proj, bucket, tempKey, proj, bucket, key, copyOverride{
+		keepExpires: true,
+	})
+	if err == nil && object.System.ContentLength != temp.System.ContentLength {
+		err = ErrObjectChanged.New("rewrite of %q has %d bytes instead of %d", key, object.System.ContentLength, temp.System.ContentLength)
+	}
+	if err != nil {
+		// the temporary copy is kept, since the object at key may be already replaced.
+		return nil, fmt.Errorf("%w (rewritten object is kept at %q)", err, tempKey)
+	}
+
+	// the parts of a replaced object uploaded in parts are deleted by the copy.
+	_, _ = proj.DeleteObject(ctx, bucket, tempKey)
+	return object, nil
+}
+
+// isRewriteKey returns whether key is a temporary copy made while rewriting an object.
+func isRewriteKey(key string) bool {
+	i := strings.LastIndex(key, rewriteKeyInfix)
+	return i >= 0 && len(key)-i-len(rewriteKeyInfix) == 32
+}
+
+// rewriteKey returns a unique temporary key for rewriting the object at key.
+func rewriteKey(key string) (string, error) {
+	id, err := newUploadID()
+	if err != nil {
+		return "", err
+	}
+	return key + rewriteKeyInfix + id, nil
+}
+
+// verifyRewrite checks that the temporary copy has the size of the stored object
+// and, when the stored object is compressed and has checksums, that its content
+// matches them. Uncompressed objects are verified while they are copied.
+func verifyRewrite(ctx context.Context, proj *Project, bucket string, stored, temp *uplink.Object) error {
+	if temp.System.ContentLength != stored.System.ContentLength {
+		return ErrObjectChanged.New("copy of %q has %d bytes instead of %d", stored.Key, temp.System.ContentLength, stored.System.ContentLength)
+	}
+	if checksumsFor(stored.Custom) == nil || compressionCodec(stored) == "" {
+		return nil
+	}
+
+	// the copy keeps the checksums of the stored object, which are verified
+	// when it is downloaded completely.
+	download, err := openDownload(ctx, proj.Project, bucket, temp.Key, &uplink.DownloadOptions{Offset: 0, Length: -1}, retryPolicy{}, parallelPolicy{}, false)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = download.Close() }()
+
+	_, err = io.Copy(ioutil.Discard, download)
+	return err
+}
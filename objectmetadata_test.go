@@ -0,0 +1,50 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestUpdatedMetadata(t *testing.T) {
+	current := uplink.CustomMetadata{
+		"a":                    "1",
+		"b":                    "2",
+		compressionMetadataKey: codecGzip,
+		manifestMetadataKey:    "",
+	}
+	update := uplink.CustomMetadata{
+		"b":                    "3",
+		"c":                    "4",
+		compressionMetadataKey: "none",
+	}
+
+	require.Equal(t, uplink.CustomMetadata{
+		"b":                    "3",
+		"c":                    "4",
+		compressionMetadataKey: codecGzip,
+	}, updatedMetadata(current, update, false))
+
+	require.Equal(t, uplink.CustomMetadata{
+		"a":                    "1",
+		"b":                    "3",
+		"c":                    "4",
+		compressionMetadataKey: codecGzip,
+	}, updatedMetadata(current, update, true))
+}
+
+func TestRewriteKey(t *testing.T) {
+	first, err := rewriteKey("dir/object")
+	require.NoError(t, err)
+	second, err := rewriteKey("dir/object")
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(first, "dir/object"+rewriteKeyInfix))
+	require.NotEqual(t, first, second)
+}
@@ -0,0 +1,436 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"storj.io/uplink"
+)
+
+// defaultReadAhead is the number of bytes read ahead when options don't specify it.
+const defaultReadAhead = 256 << 10
+
+// ObjectReader provides random access to the data of an object.
+type ObjectReader struct {
+	scope
+	project *uplink.Project
+	bucket  string
+	key     string
+	object  *uplink.Object
+	// manifest lists the parts of an object uploaded in parts.
+	manifest *manifest
+
+	mu        sync.Mutex
+	position  int64
+	readAhead int
+
+	cache       []byte
+	cacheOffset int64
+
+	stream       io.ReadCloser
+	streamOffset int64
+}
+
+//export uplink_open_object_reader
+// uplink_open_object_reader opens the object at the specified key for random access reading.
+//
+// Compressed objects cannot be read randomly and return UPLINK_ERROR_UNSUPPORTED.
+// Objects uploaded in parts are read from their parts.
+func uplink_open_object_reader(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_ObjectReaderOptions) C.Uplink_ObjectReaderResult { //nolint:golint
+	return uplink_open_object_reader_with_cancel(project, bucket_name, object_key, options, nil)
+}
+
+//export uplink_open_object_reader_with_cancel
+// uplink_open_object_reader_with_cancel opens the object at the specified key for random access reading.
+//
+// The reader is canceled when token is canceled or its deadline passes.
+func uplink_open_object_reader_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_ObjectReaderOptions, token *C.Uplink_CancelToken) C.Uplink_ObjectReaderResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_ObjectReaderResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_ObjectReaderResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+	if object_key == nil {
+		return C.Uplink_ObjectReaderResult{
+			error: mallocError(ErrNull.New("object_key")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ObjectReaderResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	readAhead := defaultReadAhead
+	if options != nil && options.read_ahead > 0 {
+		var ok bool
+		readAhead, ok = safeConvertToInt(options.read_ahead)
+		if !ok {
+			return C.Uplink_ObjectReaderResult{
+				error: mallocError(ErrInvalidArg.New("read_ahead too large")),
+			}
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ObjectReaderResult{
+			error: mallocError(err),
+		}
+	}
+	bucket, key := C.GoString(bucket_name), C.GoString(object_key)
+
+	object, err := proj.StatObject(scope.ctx, bucket, key)
+	var m *manifest
+	if err == nil && isManifest(object) {
+		m, err = readManifest(scope.ctx, proj.Project, bucket, key)
+		if err == nil {
+			object = manifestObject(object, m)
+		}
+	}
+	if err == nil {
+		err = checkUncompressed(object)
+	}
+	if err != nil {
+		scope.cancel()
+		return C.Uplink_ObjectReaderResult{
+			error: mallocError(err),
+		}
+	}
+
+	return C.Uplink_ObjectReaderResult{
+		reader: (*C.Uplink_ObjectReader)(mallocHandle(universe.Add(&ObjectReader{
+			scope:     scope,
+			project:   proj.Project,
+			bucket:    bucket,
+			key:       key,
+			object:    object,
+			manifest:  m,
+			readAhead: readAhead,
+		}))),
+	}
+}
+
+//export uplink_object_reader_read_at
+// uplink_object_reader_read_at reads up to length bytes into bytes starting at offset in the object.
+// It does not change the position used by uplink_object_reader_read.
+// It returns the number of bytes read (0 <= bytes_read <= length) and
+// any error encountered that caused the read to stop early.
+func uplink_object_reader_read_at(reader *C.Uplink_ObjectReader, offset C.int64_t, bytes unsafe.Pointer, length C.size_t) C.Uplink_ReadResult {
+	if reader == nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrNull.New("reader")),
+		}
+	}
+
+	rd, ok := universe.Get(reader._handle).(*ObjectReader)
+	if !ok {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrInvalidHandle.New("reader")),
+		}
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrInvalidArg.New("length too large")),
+		}
+	}
+
+	var buf []byte
+	*(*reflect.SliceHeader)(unsafe.Pointer(&buf)) = reflect.SliceHeader{
+		Data: uintptr(bytes),
+		Len:  ilength,
+		Cap:  ilength,
+	}
+
+	n, err := rd.ReadAt(buf, int64(offset))
+	return C.Uplink_ReadResult{
+		bytes_read: C.size_t(n),
+		error:      mallocError(err),
+	}
+}
+
+//export uplink_object_reader_read
+// uplink_object_reader_read reads up to length bytes into bytes from the current position
+// and advances the position by the number of bytes read.
+func uplink_object_reader_read(reader *C.Uplink_ObjectReader, bytes unsafe.Pointer, length C.size_t) C.Uplink_ReadResult {
+	if reader == nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrNull.New("reader")),
+		}
+	}
+
+	rd, ok := universe.Get(reader._handle).(*ObjectReader)
+	if !ok {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrInvalidHandle.New("reader")),
+		}
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrInvalidArg.New("length too large")),
+		}
+	}
+
+	var buf []byte
+	*(*reflect.SliceHeader)(unsafe.Pointer(&buf)) = reflect.SliceHeader{
+		Data: uintptr(bytes),
+		Len:  ilength,
+		Cap:  ilength,
+	}
+
+	n, err := rd.Read(buf)
+	return C.Uplink_ReadResult{
+		bytes_read: C.size_t(n),
+		error:      mallocError(err),
+	}
+}
+
+//export uplink_object_reader_seek
+// uplink_object_reader_seek sets the position for the next uplink_object_reader_read.
+// whence is one of SEEK_SET, SEEK_CUR or SEEK_END.
+func uplink_object_reader_seek(reader *C.Uplink_ObjectReader, offset C.int64_t, whence C.int) C.Uplink_SeekResult {
+	if reader == nil {
+		return C.Uplink_SeekResult{
+			error: mallocError(ErrNull.New("reader")),
+		}
+	}
+
+	rd, ok := universe.Get(reader._handle).(*ObjectReader)
+	if !ok {
+		return C.Uplink_SeekResult{
+			error: mallocError(ErrInvalidHandle.New("reader")),
+		}
+	}
+
+	var seekWhence int
+	switch whence {
+	case C.SEEK_SET:
+		seekWhence = io.SeekStart
+	case C.SEEK_CUR:
+		seekWhence = io.SeekCurrent
+	case C.SEEK_END:
+		seekWhence = io.SeekEnd
+	default:
+		return C.Uplink_SeekResult{
+			error: mallocError(ErrInvalidArg.New("whence")),
+		}
+	}
+
+	position, err := rd.Seek(int64(offset), seekWhence)
+	return C.Uplink_SeekResult{
+		position: C.int64_t(position),
+		error:    mallocError(err),
+	}
+}
+
+//export uplink_object_reader_info
+// uplink_object_reader_info returns information about the object being read.
+func uplink_object_reader_info(reader *C.Uplink_ObjectReader) C.Uplink_ObjectResult {
+	if reader == nil {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrNull.New("reader")),
+		}
+	}
+
+	rd, ok := universe.Get(reader._handle).(*ObjectReader)
+	if !ok {
+		return C.Uplink_ObjectResult{
+			error: mallocError(ErrInvalidHandle.New("reader")),
+		}
+	}
+
+	object, err := publicObject(rd.scope.ctx, rd.project, rd.bucket, rd.object, false)
+	return C.Uplink_ObjectResult{
+		object: mallocObject(object),
+		error:  mallocError(err),
+	}
+}
+
+// Read reads from the current position and advances it.
+func (rd *ObjectReader) Read(p []byte) (int, error) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	n, err := rd.readAt(p, rd.position)
+	rd.position += int64(n)
+	return n, err
+}
+
+// ReadAt reads len(p) bytes starting at off.
+func (rd *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	return rd.readAt(p, off)
+}
+
+// Seek sets the position of the next Read.
+func (rd *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	switch whence {
+	case io.SeekCurrent:
+		offset += rd.position
+	case io.SeekEnd:
+		offset += rd.object.System.ContentLength
+	}
+	if offset < 0 {
+		return rd.position, ErrInvalidArg.New("negative position")
+	}
+
+	rd.position = offset
+	return rd.position, nil
+}
+
+// readAt serves the read from the read-ahead cache and fetches the missing data, rd.mu must be held.
+func (rd *ObjectReader) readAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, ErrInvalidArg.New("negative offset")
+	}
+
+	size := rd.object.System.ContentLength
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= size {
+			return n, io.EOF
+		}
+
+		if pos >= rd.cacheOffset && pos < rd.cacheOffset+int64(len(rd.cache)) {
+			n += copy(p[n:], rd.cache[pos-rd.cacheOffset:])
+			continue
+		}
+
+		// large reads bypass the cache
+		if len(p)-n >= rd.readAhead {
+			k, err := rd.fetch(pos, p[n:])
+			n += k
+			if err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		if cap(rd.cache) < rd.readAhead {
+			rd.cache = make([]byte, rd.readAhead)
+		}
+		k, err := rd.fetch(pos, rd.cache[:rd.readAhead])
+		rd.cache, rd.cacheOffset = rd.cache[:k], pos
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// fetch reads data starting at offset into buf until it's full or the object ends.
+func (rd *ObjectReader) fetch(offset int64, buf []byte) (int, error) {
+	if remaining := rd.object.System.ContentLength - offset; int64(len(buf)) > remaining {
+		buf = buf[:remaining]
+	}
+
+	if rd.stream == nil || rd.streamOffset != offset {
+		rd.closeStream()
+
+		stream, err := rd.openStream(offset)
+		if err != nil {
+			return 0, err
+		}
+		rd.stream, rd.streamOffset = stream, offset
+	}
+
+	n, err := io.ReadFull(rd.stream, buf)
+	rd.streamOffset += int64(n)
+	if err != nil {
+		rd.closeStream()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return n, ErrObjectChanged.New("%q is shorter than expected", rd.key)
+		}
+		return n, err
+	}
+
+	return n, nil
+}
+
+// openStream starts a ranged download from offset to the end of the object.
+func (rd *ObjectReader) openStream(offset int64) (io.ReadCloser, error) {
+	opts := &uplink.DownloadOptions{
+		Offset: offset,
+		Length: -1,
+	}
+	if rd.manifest != nil {
+		// parts are stored under the id of their upload and never overwritten.
+		return newSequentialDownload(rd.scope.ctx, rd.project, rd.bucket, rd.object, manifestSegments(rd.manifest, opts, 0), retryPolicy{}), nil
+	}
+
+	download, err := rd.project.DownloadObject(rd.scope.ctx, rd.bucket, rd.key, opts)
+	if err != nil {
+		return nil, err
+	}
+	// the object may have been replaced since the reader was opened.
+	if !sameObject(download.Info(), rd.object) {
+		_ = download.Close()
+		return nil, ErrObjectChanged.New("%q", rd.key)
+	}
+	return download, nil
+}
+
+// closeStream closes the current ranged download.
+func (rd *ObjectReader) closeStream() {
+	if rd.stream != nil {
+		_ = rd.stream.Close()
+		rd.stream = nil
+	}
+}
+
+//export uplink_free_object_reader_result
+// uplink_free_object_reader_result closes the reader and frees any associated resources.
+func uplink_free_object_reader_result(result C.Uplink_ObjectReaderResult) {
+	uplink_free_error(result.error)
+	freeObjectReader(result.reader)
+}
+
+//export uplink_free_seek_result
+// uplink_free_seek_result frees any resources associated with seek result.
+func uplink_free_seek_result(result C.Uplink_SeekResult) {
+	uplink_free_error(result.error)
+}
+
+func freeObjectReader(reader *C.Uplink_ObjectReader) {
+	if reader == nil {
+		return
+	}
+	defer C.free(unsafe.Pointer(reader))
+	defer universe.Del(reader._handle)
+
+	rd, ok := universe.Get(reader._handle).(*ObjectReader)
+	if !ok {
+		return
+	}
+
+	rd.cancel()
+
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	rd.closeStream()
+}
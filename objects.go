@@ -6,6 +6,13 @@ package main
 // #include "uplink_definitions.h"
 import "C"
 import (
+	"context"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 	"unsafe"
 
 	"storj.io/uplink"
@@ -14,14 +21,23 @@ import (
 // ObjectIterator is an iterator over objects.
 type ObjectIterator struct {
 	scope
-	iterator *uplink.ObjectIterator
+	iterator *filteredObjects
 
 	initialError error
+	async        asyncQueue
 }
 
 //export uplink_list_objects
 // uplink_list_objects lists objects.
 func uplink_list_objects(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_ListObjectsOptions) *C.Uplink_ObjectIterator { //nolint:golint
+	return uplink_list_objects_with_cancel(project, bucket_name, options, nil)
+}
+
+//export uplink_list_objects_with_cancel
+// uplink_list_objects_with_cancel lists objects.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_list_objects_with_cancel(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_ListObjectsOptions, token *C.Uplink_CancelToken) *C.Uplink_ObjectIterator { //nolint:golint
 	if project == nil {
 		return (*C.Uplink_ObjectIterator)(mallocHandle(universe.Add(&ObjectIterator{
 			initialError: ErrNull.New("project"),
@@ -39,18 +55,21 @@ func uplink_list_objects(project *C.Uplink_Project, bucket_name *C.char, options
 		})))
 	}
 
-	opts := &uplink.ListObjectsOptions{}
-	if options != nil {
-		opts.Prefix = C.GoString(options.prefix)
-		opts.Cursor = C.GoString(options.cursor)
-		opts.Recursive = bool(options.recursive)
-
-		opts.System = bool(options.system)
-		opts.Custom = bool(options.custom)
+	opts := listObjectsOptions(options)
+	filter, err := listObjectsFilter(options)
+	if err != nil {
+		return (*C.Uplink_ObjectIterator)(mallocHandle(universe.Add(&ObjectIterator{
+			initialError: err,
+		})))
 	}
 
-	scope := proj.scope.child()
-	iterator := proj.ListObjects(scope.ctx, C.GoString(bucket_name), opts)
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return (*C.Uplink_ObjectIterator)(mallocHandle(universe.Add(&ObjectIterator{
+			initialError: err,
+		})))
+	}
+	iterator := listObjects(scope.ctx, proj.Project, C.GoString(bucket_name), opts, filter)
 
 	return (*C.Uplink_ObjectIterator)(mallocHandle(universe.Add(&ObjectIterator{
 		scope:    scope,
@@ -58,6 +77,56 @@ func uplink_list_objects(project *C.Uplink_Project, bucket_name *C.char, options
 	})))
 }
 
+//export uplink_list_objects_async
+// uplink_list_objects_async starts listing objects.
+//
+// on_object is invoked for every listed object and takes ownership of it. When
+// on_object returns false the listing stops. on_done is invoked once the listing
+// finishes, fails or stops. The listing is canceled when the project is closed.
+// When the listing cannot be started, an error is returned and no callback is invoked.
+func uplink_list_objects_async(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_ListObjectsOptions, on_object C.Uplink_ObjectCallback, on_done C.Uplink_ErrorCallback, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if project == nil {
+		return mallocError(ErrNull.New("project"))
+	}
+	if bucket_name == nil {
+		return mallocError(ErrNull.New("bucket_name"))
+	}
+	if on_object == nil {
+		return mallocError(ErrNull.New("on_object"))
+	}
+	if on_done == nil {
+		return mallocError(ErrNull.New("on_done"))
+	}
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("project"))
+	}
+
+	opts := listObjectsOptions(options)
+	filter, err := listObjectsFilter(options)
+	if err != nil {
+		return mallocError(err)
+	}
+
+	scope := proj.scope.child()
+	iterator := listObjects(scope.ctx, proj.Project, C.GoString(bucket_name), opts, filter)
+
+	go func() {
+		defer scope.cancel()
+
+		for iterator.Next() {
+			if !callObjectCallback(on_object, mallocObject(iterator.Item()), user_data) {
+				callErrorCallback(on_done, nil, user_data)
+				return
+			}
+		}
+
+		callErrorCallback(on_done, iterator.Err(), user_data)
+	}()
+
+	return nil
+}
+
 //export uplink_object_iterator_next
 // upink_object_iterator_next prepares next Object for reading.
 //
@@ -78,6 +147,41 @@ func uplink_object_iterator_next(iterator *C.Uplink_ObjectIterator) C.bool {
 	return C.bool(iter.iterator.Next())
 }
 
+//export uplink_object_iterator_next_queued
+// uplink_object_iterator_next_queued starts preparing the next Object for reading.
+//
+// Once it finishes a completion with user_data is added to the queue, where has_next
+// reports the same value as uplink_object_iterator_next. When it cannot be started,
+// an error is returned and no completion is added.
+func uplink_object_iterator_next_queued(iterator *C.Uplink_ObjectIterator, queue *C.Uplink_CompletionQueue, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if iterator == nil {
+		return mallocError(ErrNull.New("iterator"))
+	}
+	if queue == nil {
+		return mallocError(ErrNull.New("queue"))
+	}
+
+	iter, ok := universe.Get(iterator._handle).(*ObjectIterator)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("iterator"))
+	}
+	completions, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("queue"))
+	}
+
+	iter.async.Go(func() {
+		hasNext := iter.initialError == nil && iter.iterator.Next()
+		completions.push(completion{
+			kind:     C.UPLINK_COMPLETION_NEXT,
+			userData: user_data,
+			hasNext:  hasNext,
+		})
+	})
+
+	return nil
+}
+
 //export uplink_object_iterator_err
 // uplink_object_iterator_err returns error, if one happened during iteration.
 func uplink_object_iterator_err(iterator *C.Uplink_ObjectIterator) *C.Uplink_Error {
@@ -127,3 +231,419 @@ func uplink_free_object_iterator(iterator *C.Uplink_ObjectIterator) {
 		}
 	}
 }
+
+//export uplink_list_objects_page
+// uplink_list_objects_page lists up to page_size objects into a single allocation.
+//
+// To continue the listing, pass the returned cursor in options. When more is false
+// the listing is complete. The result must be freed with uplink_free_object_page_result.
+func uplink_list_objects_page(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_ListObjectsOptions, page_size C.size_t) C.Uplink_ObjectPageResult { //nolint:golint
+	return uplink_list_objects_page_with_cancel(project, bucket_name, options, page_size, nil)
+}
+
+//export uplink_list_objects_page_with_cancel
+// uplink_list_objects_page_with_cancel lists up to page_size objects into a single allocation.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_list_objects_page_with_cancel(project *C.Uplink_Project, bucket_name *C.char, options *C.Uplink_ListObjectsOptions, page_size C.size_t, token *C.Uplink_CancelToken) C.Uplink_ObjectPageResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+
+	pageSize, ok := safeConvertToInt(page_size)
+	if !ok || pageSize <= 0 {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(ErrInvalidArg.New("page_size")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	opts := listObjectsOptions(options)
+	filter, err := listObjectsFilter(options)
+	if err != nil {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(err),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	iterator := listObjects(scope.ctx, proj.Project, C.GoString(bucket_name), opts, filter)
+
+	var objects []*uplink.Object
+	more := false
+	for iterator.Next() {
+		if len(objects) == pageSize {
+			more = true
+			break
+		}
+		objects = append(objects, iterator.Item())
+	}
+	if err := iterator.Err(); err != nil {
+		return C.Uplink_ObjectPageResult{
+			error: mallocError(err),
+		}
+	}
+
+	cursor := opts.Cursor
+	if len(objects) > 0 {
+		// the cursor is relative to the prefix.
+		cursor = strings.TrimPrefix(objects[len(objects)-1].Key, opts.Prefix)
+	}
+
+	return objectPageToC(objects, cursor, more)
+}
+
+//export uplink_free_object_page_result
+// uplink_free_object_page_result frees the page and all objects in it.
+func uplink_free_object_page_result(result C.Uplink_ObjectPageResult) {
+	uplink_free_error(result.error)
+	C.free(unsafe.Pointer(result.objects))
+}
+
+// objectPageToC copies the objects and the cursor into a single allocation.
+func objectPageToC(objects []*uplink.Object, cursor string, more bool) C.Uplink_ObjectPageResult {
+	size := uintptr(len(objects)) * C.sizeof_Uplink_Object
+	for _, object := range objects {
+		size += uintptr(len(object.Custom)) * C.sizeof_Uplink_CustomMetadataEntry
+	}
+	for _, object := range objects {
+		size += cstringSize(object.Key)
+		for k, v := range object.Custom {
+			size += cstringSize(k) + cstringSize(v)
+		}
+	}
+	size += cstringSize(cursor)
+
+	mem := newArena(size)
+
+	var array []C.Uplink_Object
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(mem.alloc(uintptr(len(objects)) * C.sizeof_Uplink_Object)),
+		Len:  len(objects),
+		Cap:  len(objects),
+	}
+
+	entries := make([][]C.Uplink_CustomMetadataEntry, len(objects))
+	for i, object := range objects {
+		n := len(object.Custom)
+		*(*reflect.SliceHeader)(unsafe.Pointer(&entries[i])) = reflect.SliceHeader{
+			Data: uintptr(mem.alloc(uintptr(n) * C.sizeof_Uplink_CustomMetadataEntry)),
+			Len:  n,
+			Cap:  n,
+		}
+	}
+
+	for i, object := range objects {
+		array[i] = C.Uplink_Object{
+			key:       mem.cstring(object.Key),
+			is_prefix: C.bool(object.IsPrefix),
+			system: C.Uplink_SystemMetadata{
+				created:        timeToUnix(object.System.Created),
+				expires:        timeToUnix(object.System.Expires),
+				content_length: C.int64_t(object.System.ContentLength),
+			},
+		}
+
+		keys := make([]string, 0, len(object.Custom))
+		for k := range object.Custom {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for k, key := range keys {
+			value := object.Custom[key]
+			entries[i][k] = C.Uplink_CustomMetadataEntry{
+				key:          mem.cstring(key),
+				key_length:   C.size_t(len(key)),
+				value:        mem.cstring(value),
+				value_length: C.size_t(len(value)),
+			}
+		}
+		if len(keys) > 0 {
+			array[i].custom = C.Uplink_CustomMetadata{
+				entries: &entries[i][0],
+				count:   C.size_t(len(keys)),
+			}
+		}
+	}
+
+	return C.Uplink_ObjectPageResult{
+		objects: (*C.Uplink_Object)(mem.base),
+		count:   C.size_t(len(objects)),
+		cursor:  mem.cstring(cursor),
+		more:    C.bool(more),
+	}
+}
+
+func listObjectsOptions(options *C.Uplink_ListObjectsOptions) *uplink.ListObjectsOptions {
+	opts := &uplink.ListObjectsOptions{}
+	if options != nil {
+		opts.Prefix = C.GoString(options.prefix)
+		opts.Cursor = C.GoString(options.cursor)
+		opts.Recursive = bool(options.recursive)
+
+		opts.System = bool(options.system)
+		opts.Custom = bool(options.custom)
+	}
+	return opts
+}
+
+// objectFilter selects listed objects on the client side.
+//
+// A nil objectFilter matches all objects.
+type objectFilter struct {
+	glob  string
+	regex *regexp.Regexp
+
+	minContentLength int64
+	maxContentLength int64
+	createdAfter     time.Time
+	createdBefore    time.Time
+	expiresBefore    time.Time
+
+	hasMetadataKey bool
+	metadataKey    string
+	hasMetadataVal bool
+	metadataValue  string
+}
+
+// listObjectsFilter creates the filter for options, nil when no filter is set.
+func listObjectsFilter(options *C.Uplink_ListObjectsOptions) (*objectFilter, error) {
+	if options == nil {
+		return nil, nil
+	}
+
+	filter := &objectFilter{
+		glob:             C.GoString(options.glob),
+		minContentLength: int64(options.min_content_length),
+		maxContentLength: int64(options.max_content_length),
+		hasMetadataKey:   options.metadata_key != nil,
+		metadataKey:      C.GoString(options.metadata_key),
+		hasMetadataVal:   options.metadata_value != nil,
+		metadataValue:    C.GoString(options.metadata_value),
+	}
+	if options.created_after > 0 {
+		filter.createdAfter = time.Unix(int64(options.created_after), 0)
+	}
+	if options.created_before > 0 {
+		filter.createdBefore = time.Unix(int64(options.created_before), 0)
+	}
+	if options.expires_before > 0 {
+		filter.expiresBefore = time.Unix(int64(options.expires_before), 0)
+	}
+
+	if filter.glob != "" {
+		if _, err := path.Match(filter.glob, ""); err != nil {
+			return nil, ErrInvalidArg.New("glob: %v", err)
+		}
+	}
+	if options.regex != nil {
+		var err error
+		filter.regex, err = regexp.Compile(C.GoString(options.regex))
+		if err != nil {
+			return nil, ErrInvalidArg.New("regex: %v", err)
+		}
+	}
+
+	if *filter == (objectFilter{}) {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// hasMetadataFilter returns whether the filter matches against object metadata.
+func (filter *objectFilter) hasMetadataFilter() bool {
+	return filter.minContentLength > 0 || filter.maxContentLength > 0 ||
+		!filter.createdAfter.IsZero() || !filter.createdBefore.IsZero() || !filter.expiresBefore.IsZero() ||
+		filter.hasMetadataKey
+}
+
+// Match returns whether object passes the filter. Prefixes only
+// match when the filter does not use object metadata.
+func (filter *objectFilter) Match(object *uplink.Object) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.glob != "" {
+		if ok, _ := path.Match(filter.glob, object.Key); !ok {
+			return false
+		}
+	}
+	if filter.regex != nil && !filter.regex.MatchString(object.Key) {
+		return false
+	}
+
+	if !filter.hasMetadataFilter() {
+		return true
+	}
+	if object.IsPrefix {
+		return false
+	}
+
+	size := object.System.ContentLength
+	if filter.minContentLength > 0 && size < filter.minContentLength {
+		return false
+	}
+	if filter.maxContentLength > 0 && size > filter.maxContentLength {
+		return false
+	}
+
+	created := object.System.Created
+	if !filter.createdAfter.IsZero() && !created.After(filter.createdAfter) {
+		return false
+	}
+	if !filter.createdBefore.IsZero() && !created.Before(filter.createdBefore) {
+		return false
+	}
+
+	expires := object.System.Expires
+	if !filter.expiresBefore.IsZero() && (expires.IsZero() || !expires.Before(filter.expiresBefore)) {
+		return false
+	}
+
+	if filter.hasMetadataKey {
+		value, ok := object.Custom[filter.metadataKey]
+		if !ok || (filter.hasMetadataVal && value != filter.metadataValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// listObjects lists the objects in bucket matching filter. Objects uploaded in parts are
+// reported as they were uploaded and their parts are hidden.
+func listObjects(ctx context.Context, project *uplink.Project, bucket string, opts *uplink.ListObjectsOptions, filter *objectFilter) *filteredObjects {
+	listOpts := *opts
+	// the filters need the metadata they match against.
+	if filter != nil && filter.hasMetadataFilter() {
+		listOpts.System = true
+	}
+	// objects uploaded in parts are recognized by their custom metadata.
+	listOpts.Custom = true
+
+	iterator := project.ListObjects(ctx, bucket, &listOpts)
+	return newFilteredObjects(ctx, project, bucket, iterator, filter, listOpts.System, opts.Custom || filter != nil && filter.hasMetadataKey)
+}
+
+// objectIterator is implemented by uplink.ObjectIterator.
+type objectIterator interface {
+	Next() bool
+	Item() *uplink.Object
+	Err() error
+}
+
+// newFilteredObjects wraps the objects listed from bucket by iterator, which must
+// include custom metadata. system and custom is the metadata to report.
+func newFilteredObjects(ctx context.Context, project *uplink.Project, bucket string, iterator objectIterator, filter *objectFilter, system, custom bool) *filteredObjects {
+	return &filteredObjects{
+		objectIterator: iterator,
+		filter:         filter,
+		ctx:            ctx,
+		project:        project,
+		bucket:         bucket,
+		system:         system,
+		custom:         custom,
+		manifests:      map[string]struct{}{},
+	}
+}
+
+// filteredObjects is an object iterator, which skips objects not matching the filter
+// and the objects used internally by the library.
+type filteredObjects struct {
+	objectIterator
+	filter *objectFilter
+
+	ctx     context.Context
+	project *uplink.Project
+	bucket  string
+	// system and custom is the metadata included in the listed objects.
+	system bool
+	custom bool
+
+	// manifests are the listed keys of objects uploaded in parts.
+	manifests map[string]struct{}
+
+	item *uplink.Object
+	err  error
+}
+
+// Next prepares the next matching object for reading.
+func (objects *filteredObjects) Next() bool {
+	if objects.err != nil {
+		return false
+	}
+
+	for objects.objectIterator.Next() {
+		object := objects.objectIterator.Item()
+		if isManifest(object) {
+			objects.manifests[object.Key] = struct{}{}
+		}
+		if objects.hidden(object) {
+			continue
+		}
+
+		public, err := publicObject(objects.ctx, objects.project, objects.bucket, object, objects.system)
+		if err != nil {
+			objects.err = err
+			return false
+		}
+		if !objects.custom {
+			public.Custom = nil
+		}
+
+		if objects.filter.Match(public) {
+			objects.item = public
+			return true
+		}
+	}
+	return false
+}
+
+// hidden returns whether object is only used internally by the library.
+func (objects *filteredObjects) hidden(object *uplink.Object) bool {
+	if !object.IsPrefix {
+		return isPartKey(object.Key) || isRewriteKey(object.Key)
+	}
+	if strings.HasSuffix(object.Key, rewriteKeyInfix) {
+		return true
+	}
+	if manifestKey := strings.TrimSuffix(object.Key, ".parts/"); manifestKey != object.Key {
+		_, ok := objects.manifests[manifestKey]
+		return ok
+	}
+	return false
+}
+
+// Item returns the current object.
+func (objects *filteredObjects) Item() *uplink.Object {
+	return objects.item
+}
+
+// Err returns the error of the listing.
+func (objects *filteredObjects) Err() error {
+	if objects.err != nil {
+		return objects.err
+	}
+	return objects.objectIterator.Err()
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+// cString reads a zero terminated string from C memory.
+func cString(p unsafe.Pointer) string {
+	var data []byte
+	for i := uintptr(0); ; i++ {
+		b := *(*byte)(unsafe.Pointer(uintptr(p) + i))
+		if b == 0 {
+			return string(data)
+		}
+		data = append(data, b)
+	}
+}
+
+func TestObjectPageToC(t *testing.T) {
+	objects := []*uplink.Object{
+		{Key: "dir/a", Custom: uplink.CustomMetadata{"b": "2", "a": "1"}},
+		{Key: "dir/sub/", IsPrefix: true},
+	}
+	objects[0].System.ContentLength = 42
+
+	page := objectPageToC(objects, "a", true)
+	defer uplink_free_object_page_result(page)
+
+	require.EqualValues(t, 2, page.count)
+	require.True(t, bool(page.more))
+	require.Equal(t, "a", cString(unsafe.Pointer(page.cursor)))
+
+	first := page.objects
+	require.Equal(t, "dir/a", cString(unsafe.Pointer(first.key)))
+	require.EqualValues(t, 42, first.system.content_length)
+	require.EqualValues(t, 2, first.custom.count)
+
+	entry := first.custom.entries
+	require.Equal(t, "a", cString(unsafe.Pointer(entry.key)))
+	require.Equal(t, "1", cString(unsafe.Pointer(entry.value)))
+
+	// advance to the next entry without naming the C type.
+	*(*unsafe.Pointer)(unsafe.Pointer(&entry)) = unsafe.Pointer(uintptr(unsafe.Pointer(entry)) + unsafe.Sizeof(*entry))
+	require.Equal(t, "b", cString(unsafe.Pointer(entry.key)))
+	require.Equal(t, "2", cString(unsafe.Pointer(entry.value)))
+
+	second := first
+	*(*unsafe.Pointer)(unsafe.Pointer(&second)) = unsafe.Pointer(uintptr(unsafe.Pointer(first)) + unsafe.Sizeof(*first))
+	require.Equal(t, "dir/sub/", cString(unsafe.Pointer(second.key)))
+	require.True(t, bool(second.is_prefix))
+	require.EqualValues(t, 0, second.custom.count)
+}
+
+func TestObjectFilter(t *testing.T) {
+	now := time.Now()
+	object := &uplink.Object{Key: "logs/2020/app.log", Custom: uplink.CustomMetadata{"type": "log"}}
+	object.System.ContentLength = 100
+	object.System.Created = now
+	object.System.Expires = now.Add(time.Hour)
+	prefix := &uplink.Object{Key: "logs/2020/", IsPrefix: true}
+
+	var all *objectFilter
+	require.True(t, all.Match(object))
+	require.True(t, all.Match(prefix))
+
+	for _, tt := range []struct {
+		filter objectFilter
+		object bool
+		prefix bool
+	}{
+		{filter: objectFilter{glob: "logs/*/*.log"}, object: true},
+		{filter: objectFilter{glob: "logs/*/"}, prefix: true},
+		{filter: objectFilter{regex: regexp.MustCompile(`\.log$`)}, object: true},
+		{filter: objectFilter{minContentLength: 100, maxContentLength: 100}, object: true},
+		{filter: objectFilter{minContentLength: 101}},
+		{filter: objectFilter{maxContentLength: 99}},
+		{filter: objectFilter{createdAfter: now.Add(-time.Minute), createdBefore: now.Add(time.Minute)}, object: true},
+		{filter: objectFilter{createdAfter: now}},
+		{filter: objectFilter{expiresBefore: now.Add(2 * time.Hour)}, object: true},
+		{filter: objectFilter{expiresBefore: now}},
+		{filter: objectFilter{hasMetadataKey: true, metadataKey: "type"}, object: true},
+		{filter: objectFilter{hasMetadataKey: true, metadataKey: "type", hasMetadataVal: true, metadataValue: "log"}, object: true},
+		{filter: objectFilter{hasMetadataKey: true, metadataKey: "type", hasMetadataVal: true, metadataValue: "json"}},
+	} {
+		filter := tt.filter
+		require.Equal(t, tt.object, filter.Match(object), "%+v", filter)
+		require.Equal(t, tt.prefix, filter.Match(prefix), "%+v", filter)
+	}
+}
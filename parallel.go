@@ -0,0 +1,384 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"storj.io/uplink"
+)
+
+// defaultPartSize is the size of a part when options don't specify it.
+const defaultPartSize = 16 << 20
+
+// parallelPolicy defines how an object is split into parts that are transferred concurrently.
+type parallelPolicy struct {
+	concurrency int
+	partSize    int64
+}
+
+func downloadParallelPolicy(options *C.Uplink_DownloadOptions) parallelPolicy {
+	if options == nil {
+		return parallelPolicy{}
+	}
+	return newParallelPolicy(options.concurrency, options.part_size)
+}
+
+func uploadParallelPolicy(options *C.Uplink_UploadOptions) parallelPolicy {
+	if options == nil {
+		return parallelPolicy{}
+	}
+	return newParallelPolicy(options.concurrency, options.part_size)
+}
+
+func newParallelPolicy(concurrency C.int32_t, partSize C.int64_t) parallelPolicy {
+	policy := parallelPolicy{
+		concurrency: int(concurrency),
+		partSize:    int64(partSize),
+	}
+	if policy.partSize <= 0 {
+		policy.partSize = defaultPartSize
+	}
+	return policy
+}
+
+// enabled returns whether the transfer should be split into concurrent parts.
+func (policy parallelPolicy) enabled() bool {
+	return policy.concurrency > 1
+}
+
+// byteRange is a part of an object.
+type byteRange struct {
+	offset int64
+	length int64
+}
+
+// splitRange splits length bytes starting at offset into parts of at most partSize bytes.
+func splitRange(offset, length, partSize int64) []byteRange {
+	var parts []byteRange
+	for length > 0 {
+		size := partSize
+		if size > length {
+			size = length
+		}
+		parts = append(parts, byteRange{offset: offset, length: size})
+		offset += size
+		length -= size
+	}
+	return parts
+}
+
+// segment is a range of bytes stored in a single object.
+type segment struct {
+	key string
+	byteRange
+	// expected is the object the stored data must belong to, when not nil.
+	expected *uplink.Object
+}
+
+// resolveSegments returns the object and the segments to download for opts,
+// each of them at most partSize bytes.
+func resolveSegments(ctx context.Context, project *uplink.Project, bucket, key string, opts *uplink.DownloadOptions, partSize int64) (*uplink.Object, []segment, error) {
+	object, err := project.StatObject(ctx, bucket, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isManifest(object) {
+		return resolveManifest(ctx, project, bucket, object, opts, partSize)
+	}
+
+	var segments []segment
+	for _, part := range splitRange(opts.Offset, downloadLength(object.System.ContentLength, opts), partSize) {
+		segments = append(segments, segment{key: key, byteRange: part, expected: object})
+	}
+	return object, segments, nil
+}
+
+// downloadSegment downloads seg into data, at the rate allowed by limiter when it is not nil.
+func downloadSegment(ctx context.Context, project *uplink.Project, bucket string, seg segment, retry retryPolicy, limiter *rateLimiter, data []byte) (int, error) {
+	opened, err := openRetryingDownload(ctx, project, bucket, seg.key, &uplink.DownloadOptions{
+		Offset: seg.offset,
+		Length: seg.length,
+	}, retry)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = opened.Close() }()
+	download := throttleDownload(ctx, opened, limiter)
+
+	if seg.expected != nil && !sameObject(download.Info(), seg.expected) {
+		return 0, ErrObjectChanged.New("%q", seg.key)
+	}
+
+	n, err := io.ReadFull(download, data)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return n, ErrObjectChanged.New("%q is shorter than expected", seg.key)
+	}
+	return n, err
+}
+
+// sameObject returns whether info describes the same upload as expected.
+func sameObject(info, expected *uplink.Object) bool {
+	return info.System.Created.Equal(expected.System.Created) && info.System.ContentLength == expected.System.ContentLength
+}
+
+// segmentDownloader downloads seg into data and returns the number of bytes read.
+type segmentDownloader func(ctx context.Context, seg segment, data []byte) (int, error)
+
+// projectSegmentDownloader downloads segments from bucket in project. The segments
+// are not throttled, since the reassembled download is.
+func projectSegmentDownloader(project *uplink.Project, bucket string, retry retryPolicy) segmentDownloader {
+	return func(ctx context.Context, seg segment, data []byte) (int, error) {
+		return downloadSegment(ctx, project, bucket, seg, retry, nil, data)
+	}
+}
+
+// parallelDownload downloads segments concurrently and returns them in order.
+type parallelDownload struct {
+	ctx    context.Context
+	cancel func()
+	object *uplink.Object
+
+	parts   []chan partResult
+	limiter chan struct{}
+
+	current partResult
+	next    int
+}
+
+// partResult is the data of a single downloaded segment.
+//
+// When err is set, data is nil, as a failed segment must not be returned as content.
+type partResult struct {
+	data []byte
+	err  error
+}
+
+// newParallelDownload starts downloading the segments, with at most concurrency
+// segments being downloaded or buffered at the same time.
+func newParallelDownload(ctx context.Context, object *uplink.Object, segments []segment, downloadSegment segmentDownloader, concurrency int) *parallelDownload {
+	ctx, cancel := context.WithCancel(ctx)
+	download := &parallelDownload{
+		ctx:     ctx,
+		cancel:  cancel,
+		object:  object,
+		parts:   make([]chan partResult, len(segments)),
+		limiter: make(chan struct{}, concurrency),
+	}
+	for i := range download.parts {
+		download.parts[i] = make(chan partResult, 1)
+	}
+
+	go func() {
+		for i, seg := range segments {
+			select {
+			case download.limiter <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(i int, seg segment) {
+				data := make([]byte, seg.length)
+				if _, err := downloadSegment(ctx, seg, data); err != nil {
+					download.parts[i] <- partResult{err: err}
+					return
+				}
+				download.parts[i] <- partResult{data: data}
+			}(i, seg)
+		}
+	}()
+
+	return download
+}
+
+// Info returns the information about the object when the download started.
+func (download *parallelDownload) Info() *uplink.Object {
+	return download.object
+}
+
+// Read reads the segments in order.
+func (download *parallelDownload) Read(p []byte) (int, error) {
+	for {
+		if download.current.err != nil {
+			return 0, download.current.err
+		}
+		if len(download.current.data) > 0 {
+			break
+		}
+		if download.next >= len(download.parts) {
+			return 0, io.EOF
+		}
+
+		select {
+		case download.current = <-download.parts[download.next]:
+		case <-download.ctx.Done():
+			return 0, download.ctx.Err()
+		}
+		download.next++
+		// the segment is now owned by the reader, allow fetching the next one
+		<-download.limiter
+	}
+
+	n := copy(p, download.current.data)
+	download.current.data = download.current.data[n:]
+	return n, nil
+}
+
+// Close stops downloading the remaining segments.
+func (download *parallelDownload) Close() error {
+	download.cancel()
+	return nil
+}
+
+//export uplink_download_into_buffer
+// uplink_download_into_buffer downloads the object at the specified key into bytes, up to length amount.
+//
+// When concurrency in options is larger than 1, the object is split into parts
+// that are downloaded in parallel.
+// It returns the number of bytes read, which is smaller than length when the object
+// or the range specified in options is shorter. When the whole object is downloaded,
+// its checksums are verified.
+func uplink_download_into_buffer(project *C.Uplink_Project, bucket_name, object_key *C.char, bytes unsafe.Pointer, length C.size_t, options *C.Uplink_DownloadOptions) C.Uplink_ReadResult { //nolint:golint
+	return uplink_download_into_buffer_with_cancel(project, bucket_name, object_key, bytes, length, options, nil)
+}
+
+//export uplink_download_into_buffer_with_cancel
+// uplink_download_into_buffer_with_cancel downloads the object at the specified key into bytes, up to length amount.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_download_into_buffer_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, bytes unsafe.Pointer, length C.size_t, options *C.Uplink_DownloadOptions, token *C.Uplink_CancelToken) C.Uplink_ReadResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+	if object_key == nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrNull.New("object_key")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return C.Uplink_ReadResult{
+			error: mallocError(ErrInvalidArg.New("length too large")),
+		}
+	}
+
+	var buf []byte
+	*(*reflect.SliceHeader)(unsafe.Pointer(&buf)) = reflect.SliceHeader{
+		Data: uintptr(bytes),
+		Len:  ilength,
+		Cap:  ilength,
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	bucket := C.GoString(bucket_name)
+	opts := downloadOptions(options)
+	if opts.Length < 0 || opts.Length > int64(len(buf)) {
+		opts.Length = int64(len(buf))
+	}
+	retry := downloadRetryPolicy(options)
+	policy := downloadParallelPolicy(options)
+	if !policy.enabled() {
+		policy.concurrency = 1
+	}
+
+	object, segments, err := resolveSegments(scope.ctx, proj.Project, bucket, C.GoString(object_key), opts, policy.partSize)
+	if err == nil && !downloadRaw(options) {
+		err = checkUncompressed(object)
+	}
+	if err != nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(err),
+		}
+	}
+
+	var total int64
+	for _, seg := range segments {
+		total += seg.length
+	}
+
+	progress := downloadProgress(options)
+	progress.SetTotal(total)
+
+	limiter := make(chan struct{}, policy.concurrency)
+	bandwidth := proj.downloadLimiter(options)
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	var position int64
+	for _, seg := range segments {
+		seg, start := seg, position
+		position += seg.length
+
+		limiter <- struct{}{}
+		if scope.ctx.Err() != nil {
+			<-limiter
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-limiter }()
+
+			n, err := downloadSegment(scope.ctx, proj.Project, bucket, seg, retry, bandwidth, buf[start:start+seg.length])
+			progress.Add(n)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					scope.cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = scope.ctx.Err()
+	}
+	if firstErr == nil && opts.Offset == 0 && total == object.System.ContentLength && compressionCodec(object) == "" {
+		// the whole object was downloaded, so its checksums can be verified.
+		firstErr = verifyChecksums(object, buf[:total])
+	}
+	if firstErr != nil {
+		return C.Uplink_ReadResult{
+			error: mallocError(firstErr),
+		}
+	}
+	progress.Finish()
+
+	return C.Uplink_ReadResult{
+		bytes_read: C.size_t(total),
+	}
+}
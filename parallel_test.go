@@ -0,0 +1,69 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestSplitRange(t *testing.T) {
+	require.Empty(t, splitRange(10, 0, 4))
+
+	require.Equal(t, []byteRange{
+		{offset: 10, length: 4},
+		{offset: 14, length: 4},
+		{offset: 18, length: 2},
+	}, splitRange(10, 10, 4))
+
+	require.Equal(t, []byteRange{
+		{offset: 0, length: 8},
+	}, splitRange(0, 8, 8))
+}
+
+func TestParallelDownloadSegmentFailure(t *testing.T) {
+	content := []byte("0123456789abcdef")
+	failure := errors.New("segment failed")
+
+	var segments []segment
+	for _, part := range splitRange(0, int64(len(content)), 4) {
+		segments = append(segments, segment{key: "key", byteRange: part})
+	}
+
+	fetch := func(ctx context.Context, seg segment, data []byte) (int, error) {
+		if seg.offset == 8 {
+			// fail partway through the third segment.
+			return copy(data[:2], content[seg.offset:]), failure
+		}
+		return copy(data, content[seg.offset:seg.offset+seg.length]), nil
+	}
+
+	download := newParallelDownload(context.Background(), nil, segments, fetch, 2)
+	defer func() { _ = download.Close() }()
+
+	data, err := ioutil.ReadAll(download)
+	require.True(t, errors.Is(err, failure))
+	require.Equal(t, content[:8], data)
+}
+
+func TestSameObject(t *testing.T) {
+	now := time.Now()
+	object := func(created time.Time, size int64) *uplink.Object {
+		object := &uplink.Object{Key: "a"}
+		object.System.Created = created
+		object.System.ContentLength = size
+		return object
+	}
+
+	require.True(t, sameObject(object(now, 10), object(now, 10)))
+	require.False(t, sameObject(object(now.Add(time.Second), 10), object(now, 10)))
+	require.False(t, sameObject(object(now, 11), object(now, 10)))
+}
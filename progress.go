@@ -0,0 +1,161 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"io"
+	"sync"
+	"time"
+	"unsafe"
+
+	"storj.io/uplink"
+)
+
+// progress reports the number of transferred bytes to a callback.
+//
+// All methods are safe to call on a nil progress.
+type progress struct {
+	callback C.Uplink_ProgressCallback
+	userData unsafe.Pointer
+	interval time.Duration
+
+	mu          sync.Mutex
+	start       time.Time
+	lastReport  time.Time
+	total       int64
+	transferred int64
+}
+
+// newProgress returns nil when callback is not set.
+func newProgress(callback C.Uplink_ProgressCallback, userData unsafe.Pointer, intervalMilliseconds C.int64_t) *progress {
+	if callback == nil {
+		return nil
+	}
+
+	now := time.Now()
+	return &progress{
+		callback:   callback,
+		userData:   userData,
+		interval:   time.Duration(intervalMilliseconds) * time.Millisecond,
+		start:      now,
+		lastReport: now,
+		total:      -1,
+	}
+}
+
+// uploadProgress creates progress reporting for upload options.
+func uploadProgress(options *C.Uplink_UploadOptions) *progress {
+	if options == nil {
+		return nil
+	}
+	return newProgress(options.progress, options.progress_user_data, options.progress_interval_milliseconds)
+}
+
+// downloadProgress creates progress reporting for download options.
+func downloadProgress(options *C.Uplink_DownloadOptions) *progress {
+	if options == nil {
+		return nil
+	}
+	return newProgress(options.progress, options.progress_user_data, options.progress_interval_milliseconds)
+}
+
+// SetTotal sets the expected number of bytes, negative when unknown.
+func (p *progress) SetTotal(total int64) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.total = total
+}
+
+// Add adds n transferred bytes and reports when the interval has passed.
+func (p *progress) Add(n int) {
+	if p == nil || n <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.transferred += int64(n)
+
+	now := time.Now()
+	if now.Sub(p.lastReport) < p.interval {
+		return
+	}
+	p.report(now)
+}
+
+// Finish reports the final number of transferred bytes.
+func (p *progress) Finish() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.report(time.Now())
+}
+
+// report invokes the callback, p.mu must be held.
+func (p *progress) report(now time.Time) {
+	p.lastReport = now
+	callProgressCallback(p.callback, p.transferred, p.total, now.Sub(p.start), p.userData)
+}
+
+// Reader wraps r to report the bytes read from it.
+func (p *progress) Reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return &progressReader{r, p}
+}
+
+// Writer wraps w to report the bytes written to it.
+func (p *progress) Writer(w io.Writer) io.Writer {
+	if p == nil {
+		return w
+	}
+	return &progressWriter{w, p}
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress *progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.progress.Add(n)
+	return n, err
+}
+
+type progressWriter struct {
+	writer   io.Writer
+	progress *progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.progress.Add(n)
+	return n, err
+}
+
+// downloadLength returns the number of bytes a download with opts returns
+// for an object with contentLength bytes.
+func downloadLength(contentLength int64, opts *uplink.DownloadOptions) int64 {
+	remaining := contentLength - opts.Offset
+	if remaining < 0 {
+		remaining = 0
+	}
+	if opts.Length >= 0 && opts.Length < remaining {
+		return opts.Length
+	}
+	return remaining
+}
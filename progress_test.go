@@ -0,0 +1,28 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestDownloadLength(t *testing.T) {
+	for _, test := range []struct {
+		offset, length int64
+		expected       int64
+	}{
+		{offset: 0, length: -1, expected: 100},
+		{offset: 10, length: -1, expected: 90},
+		{offset: 10, length: 20, expected: 20},
+		{offset: 90, length: 20, expected: 10},
+		{offset: 200, length: -1, expected: 0},
+	} {
+		opts := &uplink.DownloadOptions{Offset: test.offset, Length: test.length}
+		require.Equal(t, test.expected, downloadLength(100, opts), "%+v", test)
+	}
+}
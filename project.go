@@ -15,11 +15,28 @@ import (
 type Project struct {
 	scope
 	*uplink.Project
+
+	// tempDir is the temp directory the project was opened with.
+	tempDir string
+	// journalScope identifies the access in the paths of upload journals.
+	journalScope string
+	// bandwidth limits the transfers of the project.
+	bandwidth *BandwidthLimiter
+	// bucketStats caches the statistics of recently walked buckets.
+	bucketStats bucketStatsCache
 }
 
 //export uplink_open_project
 // uplink_open_project opens project using access grant.
 func uplink_open_project(access *C.Uplink_Access) C.Uplink_ProjectResult {
+	return uplink_open_project_with_cancel(access, nil)
+}
+
+//export uplink_open_project_with_cancel
+// uplink_open_project_with_cancel opens project using access grant.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_open_project_with_cancel(access *C.Uplink_Access, token *C.Uplink_CancelToken) C.Uplink_ProjectResult {
 	if access == nil {
 		return C.Uplink_ProjectResult{
 			error: mallocError(ErrNull.New("access")),
@@ -36,15 +53,25 @@ func uplink_open_project(access *C.Uplink_Access) C.Uplink_ProjectResult {
 	scope := rootScope("")
 	config := uplink.Config{}
 
-	proj, err := config.OpenProject(scope.ctx, acc.Access)
+	dial, err := scope.childWithCancel(token)
+	if err != nil {
+		scope.cancel()
+		return C.Uplink_ProjectResult{
+			error: mallocError(err),
+		}
+	}
+	defer dial.cancel()
+
+	proj, err := config.OpenProject(dial.ctx, acc.Access)
 	if err != nil {
+		scope.cancel()
 		return C.Uplink_ProjectResult{
 			error: mallocError(err),
 		}
 	}
 
 	return C.Uplink_ProjectResult{
-		project: (*C.Uplink_Project)(mallocHandle(universe.Add(&Project{scope, proj}))),
+		project: (*C.Uplink_Project)(mallocHandle(universe.Add(&Project{scope: scope, Project: proj, journalScope: journalScope(acc.Access), bandwidth: newBandwidthLimiter(0, 0)}))),
 	}
 }
 
@@ -0,0 +1,170 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"storj.io/uplink"
+)
+
+// retryPolicy defines how failed reads are retried.
+type retryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+}
+
+func downloadRetryPolicy(options *C.Uplink_DownloadOptions) retryPolicy {
+	if options == nil {
+		return retryPolicy{}
+	}
+	return retryPolicy{
+		maxRetries: int(options.max_retries),
+		backoff:    time.Duration(options.retry_backoff_milliseconds) * time.Millisecond,
+	}
+}
+
+// delay returns how long to wait before the specified attempt, starting from 1.
+func (policy retryPolicy) delay(attempt int) time.Duration {
+	if policy.backoff <= 0 || attempt <= 0 {
+		return 0
+	}
+	if attempt > 16 {
+		attempt = 16
+	}
+	return policy.backoff << uint(attempt-1)
+}
+
+// openRetryingDownload starts a download, which retries failed reads according to policy.
+func openRetryingDownload(ctx context.Context, project *uplink.Project, bucket, key string, opts *uplink.DownloadOptions, policy retryPolicy) (objectDownload, error) {
+	download, err := project.DownloadObject(ctx, bucket, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	if policy.maxRetries <= 0 {
+		return download, nil
+	}
+
+	return &retryingDownload{
+		ctx:      ctx,
+		project:  project,
+		bucket:   bucket,
+		key:      key,
+		offset:   opts.Offset,
+		length:   opts.Length,
+		policy:   policy,
+		download: download,
+		object:   download.Info(),
+	}, nil
+}
+
+// retryingDownload reopens the object at the current offset when a read fails.
+type retryingDownload struct {
+	ctx     context.Context
+	project *uplink.Project
+	bucket  string
+	key     string
+	offset  int64
+	length  int64
+	policy  retryPolicy
+
+	download *uplink.Download
+	object   *uplink.Object
+	read     int64
+	attempts int
+}
+
+// Info returns the information about the object when the download started.
+func (download *retryingDownload) Info() *uplink.Object {
+	return download.object
+}
+
+// Read reads from the object and retries transient failures.
+func (download *retryingDownload) Read(p []byte) (int, error) {
+	n, err := download.download.Read(p)
+	download.read += int64(n)
+	if err == nil {
+		download.attempts = 0
+		return n, nil
+	}
+
+	for download.retryable(err) && download.attempts < download.policy.maxRetries {
+		download.attempts++
+		err = download.reopen()
+		if err == nil {
+			if n > 0 {
+				return n, nil
+			}
+			return download.Read(p)
+		}
+	}
+
+	return n, err
+}
+
+// Close closes the current download.
+func (download *retryingDownload) Close() error {
+	return download.download.Close()
+}
+
+// retryable returns whether a failure may be fixed by reopening the download.
+func (download *retryingDownload) retryable(err error) bool {
+	if download.ctx.Err() != nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, io.EOF),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		ErrObjectChanged.Has(err),
+		errors.Is(err, uplink.ErrBandwidthLimitExceeded),
+		errors.Is(err, uplink.ErrBucketNotFound),
+		errors.Is(err, uplink.ErrObjectNotFound),
+		errors.Is(err, uplink.ErrObjectKeyInvalid):
+		return false
+	}
+	return true
+}
+
+// reopen starts a new download from the current offset.
+func (download *retryingDownload) reopen() error {
+	_ = download.download.Close()
+
+	timer := time.NewTimer(download.policy.delay(download.attempts))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-download.ctx.Done():
+		return download.ctx.Err()
+	}
+
+	opts := &uplink.DownloadOptions{
+		Offset: download.offset + download.read,
+		Length: -1,
+	}
+	if download.length >= 0 {
+		opts.Length = download.length - download.read
+	}
+
+	reopened, err := download.project.DownloadObject(download.ctx, download.bucket, download.key, opts)
+	if err != nil {
+		return err
+	}
+
+	info := reopened.Info()
+	if !info.System.Created.Equal(download.object.System.Created) ||
+		info.System.ContentLength != download.object.System.ContentLength {
+		_ = reopened.Close()
+		return ErrObjectChanged.New("%q", download.key)
+	}
+
+	download.download = reopened
+	return nil
+}
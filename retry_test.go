@@ -0,0 +1,21 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	require.Equal(t, time.Duration(0), retryPolicy{maxRetries: 3}.delay(1))
+
+	policy := retryPolicy{maxRetries: 3, backoff: 100 * time.Millisecond}
+	require.Equal(t, 100*time.Millisecond, policy.delay(1))
+	require.Equal(t, 200*time.Millisecond, policy.delay(2))
+	require.Equal(t, 400*time.Millisecond, policy.delay(3))
+	require.Equal(t, policy.delay(16), policy.delay(100))
+}
@@ -32,3 +32,28 @@ func (parent *scope) child() scope {
 	ctx, cancel := context.WithCancel(parent.ctx)
 	return scope{ctx, cancel}
 }
+
+// childWithContext creates an inherited scope, which is additionally canceled
+// when other is done. The deadline of other is propagated to the scope.
+func (parent *scope) childWithContext(other context.Context) scope {
+	var ctx context.Context
+	var cancel func()
+	if deadline, ok := other.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(parent.ctx, deadline)
+	} else {
+		ctx, cancel = context.WithCancel(parent.ctx)
+	}
+
+	go func() {
+		select {
+		case <-other.Done():
+			// let the deadline trigger on its own to report the correct error.
+			if other.Err() != context.DeadlineExceeded {
+				cancel()
+			}
+		case <-ctx.Done():
+		}
+	}()
+
+	return scope{ctx, cancel}
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScope_ChildWithContext(t *testing.T) {
+	{ // canceling other cancels the child, but not the parent
+		parent := rootScope("inmemory")
+		defer parent.cancel()
+
+		other, cancel := context.WithCancel(context.Background())
+		child := parent.childWithContext(other)
+		defer child.cancel()
+
+		cancel()
+		<-child.ctx.Done()
+		require.Equal(t, context.Canceled, child.ctx.Err())
+		require.NoError(t, parent.ctx.Err())
+	}
+
+	{ // deadline of other is reported as deadline exceeded
+		parent := rootScope("inmemory")
+		defer parent.cancel()
+
+		other, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		child := parent.childWithContext(other)
+		defer child.cancel()
+
+		<-child.ctx.Done()
+		require.Equal(t, context.DeadlineExceeded, child.ctx.Err())
+	}
+
+	{ // canceling the parent cancels the child
+		parent := rootScope("inmemory")
+		child := parent.childWithContext(context.Background())
+		defer child.cancel()
+
+		parent.cancel()
+		<-child.ctx.Done()
+		require.Equal(t, context.Canceled, child.ctx.Err())
+	}
+}
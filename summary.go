@@ -0,0 +1,237 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+// #include "uplink_definitions.h"
+import "C"
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+
+	"storj.io/uplink"
+)
+
+//export uplink_summarize_prefix
+// uplink_summarize_prefix returns the number of objects and bytes under prefix, including
+// nested prefixes. When prefix is empty, the whole bucket is summarized.
+//
+// With options depth, the result additionally contains a summary for every nested prefix
+// up to depth levels below prefix.
+func uplink_summarize_prefix(project *C.Uplink_Project, bucket_name, prefix *C.char, options *C.Uplink_SummarizePrefixOptions) C.Uplink_SummarizePrefixResult { //nolint:golint
+	return uplink_summarize_prefix_with_cancel(project, bucket_name, prefix, options, nil)
+}
+
+//export uplink_summarize_prefix_with_cancel
+// uplink_summarize_prefix_with_cancel returns the number of objects and bytes under prefix.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_summarize_prefix_with_cancel(project *C.Uplink_Project, bucket_name, prefix *C.char, options *C.Uplink_SummarizePrefixOptions, token *C.Uplink_CancelToken) C.Uplink_SummarizePrefixResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_SummarizePrefixResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_SummarizePrefixResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+	if prefix == nil {
+		return C.Uplink_SummarizePrefixResult{
+			error: mallocError(ErrNull.New("prefix")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_SummarizePrefixResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_SummarizePrefixResult{
+			error: mallocError(err),
+		}
+	}
+	defer scope.cancel()
+
+	s := newSummarizer(C.GoString(prefix), 0)
+	var report func(objects, bytes int64)
+	var interval time.Duration
+	if options != nil {
+		s.depth = int(options.depth)
+		if options.progress != nil {
+			callback, userData := options.progress, options.progress_user_data
+			report = func(objects, bytes int64) {
+				callSummaryProgressCallback(callback, objects, bytes, userData)
+			}
+			interval = time.Duration(options.progress_interval_milliseconds) * time.Millisecond
+		}
+	}
+
+	if err := s.walk(scope.ctx, proj.Project, C.GoString(bucket_name), report, interval); err != nil {
+		return C.Uplink_SummarizePrefixResult{
+			error: mallocError(err),
+		}
+	}
+
+	children := s.sortedChildren()
+	result := C.Uplink_SummarizePrefixResult{
+		summary:        prefixSummaryToC(s.total, C.CString(s.total.prefix)),
+		children_count: C.size_t(len(children)),
+	}
+	if len(children) > 0 {
+		result.children = prefixSummariesToC(children)
+	}
+	return result
+}
+
+//export uplink_free_summarize_prefix_result
+// uplink_free_summarize_prefix_result frees any resources associated with the summary.
+func uplink_free_summarize_prefix_result(result C.Uplink_SummarizePrefixResult) {
+	uplink_free_error(result.error)
+	C.free(unsafe.Pointer(result.summary.prefix))
+	C.free(unsafe.Pointer(result.children))
+}
+
+// prefixSummary is the usage of objects under a prefix.
+type prefixSummary struct {
+	prefix  string
+	objects int64
+	bytes   int64
+	oldest  time.Time
+	newest  time.Time
+}
+
+// add adds the object to the summary.
+func (summary *prefixSummary) add(object *uplink.Object) {
+	summary.objects++
+	summary.bytes += object.System.ContentLength
+
+	created := object.System.Created
+	if summary.oldest.IsZero() || created.Before(summary.oldest) {
+		summary.oldest = created
+	}
+	if created.After(summary.newest) {
+		summary.newest = created
+	}
+}
+
+// summarizer collects the usage of a prefix and its nested prefixes up to depth.
+type summarizer struct {
+	depth    int
+	total    prefixSummary
+	children map[string]*prefixSummary
+}
+
+func newSummarizer(prefix string, depth int) *summarizer {
+	return &summarizer{
+		depth:    depth,
+		total:    prefixSummary{prefix: prefix},
+		children: map[string]*prefixSummary{},
+	}
+}
+
+// add adds the object to the total and the nested prefixes containing it.
+func (s *summarizer) add(object *uplink.Object) {
+	s.total.add(object)
+
+	rest := strings.TrimPrefix(object.Key, s.total.prefix)
+	end := len(s.total.prefix)
+	for level := 0; level < s.depth; level++ {
+		i := strings.IndexByte(rest, '/')
+		if i < 0 {
+			return
+		}
+		end += i + 1
+		rest = rest[i+1:]
+
+		child := object.Key[:end]
+		summary, ok := s.children[child]
+		if !ok {
+			summary = &prefixSummary{prefix: child}
+			s.children[child] = summary
+		}
+		summary.add(object)
+	}
+}
+
+// walk lists all objects under the prefix and reports the progress every interval.
+// Objects uploaded in parts are counted once with the size they were uploaded with.
+func (s *summarizer) walk(ctx context.Context, project *uplink.Project, bucket string, report func(objects, bytes int64), interval time.Duration) error {
+	iterator := listObjects(ctx, project, bucket, &uplink.ListObjectsOptions{
+		Prefix:    s.total.prefix,
+		Recursive: true,
+		System:    true,
+	}, nil)
+	return s.walkObjects(iterator, report, interval)
+}
+
+// walkObjects adds the objects of iterator and reports the progress every interval.
+func (s *summarizer) walkObjects(iterator *filteredObjects, report func(objects, bytes int64), interval time.Duration) error {
+	lastReport := time.Now()
+	for iterator.Next() {
+		s.add(iterator.Item())
+
+		if report != nil && time.Since(lastReport) >= interval {
+			report(s.total.objects, s.total.bytes)
+			lastReport = time.Now()
+		}
+	}
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+
+	if report != nil {
+		report(s.total.objects, s.total.bytes)
+	}
+	return nil
+}
+
+// sortedChildren returns the nested prefix summaries ordered by prefix.
+func (s *summarizer) sortedChildren() []*prefixSummary {
+	children := make([]*prefixSummary, 0, len(s.children))
+	for _, child := range s.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, k int) bool { return children[i].prefix < children[k].prefix })
+	return children
+}
+
+func prefixSummaryToC(summary prefixSummary, prefix *C.char) C.Uplink_PrefixSummary {
+	return C.Uplink_PrefixSummary{
+		prefix:         prefix,
+		objects:        C.int64_t(summary.objects),
+		bytes:          C.int64_t(summary.bytes),
+		oldest_created: timeToUnix(summary.oldest),
+		newest_created: timeToUnix(summary.newest),
+	}
+}
+
+// prefixSummariesToC copies the summaries into a single allocation.
+func prefixSummariesToC(summaries []*prefixSummary) *C.Uplink_PrefixSummary {
+	size := uintptr(len(summaries)) * C.sizeof_Uplink_PrefixSummary
+	for _, summary := range summaries {
+		size += cstringSize(summary.prefix)
+	}
+	mem := newArena(size)
+
+	var array []C.Uplink_PrefixSummary
+	*(*reflect.SliceHeader)(unsafe.Pointer(&array)) = reflect.SliceHeader{
+		Data: uintptr(mem.alloc(uintptr(len(summaries)) * C.sizeof_Uplink_PrefixSummary)),
+		Len:  len(summaries),
+		Cap:  len(summaries),
+	}
+
+	for i, summary := range summaries {
+		array[i] = prefixSummaryToC(*summary, mem.cstring(summary.prefix))
+	}
+	return (*C.Uplink_PrefixSummary)(mem.base)
+}
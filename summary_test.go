@@ -0,0 +1,93 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"storj.io/uplink"
+)
+
+func TestSummarizer(t *testing.T) {
+	now := time.Now()
+	object := func(key string, size int64, created time.Time) *uplink.Object {
+		object := &uplink.Object{Key: key}
+		object.System.ContentLength = size
+		object.System.Created = created
+		return object
+	}
+
+	s := newSummarizer("data/", 2)
+	s.add(object("data/a", 1, now))
+	s.add(object("data/x/b", 10, now.Add(-time.Hour)))
+	s.add(object("data/x/y/c", 100, now.Add(time.Hour)))
+	s.add(object("data/x/y/z/d", 1000, now))
+
+	require.Equal(t, int64(4), s.total.objects)
+	require.Equal(t, int64(1111), s.total.bytes)
+	require.Equal(t, now.Add(-time.Hour), s.total.oldest)
+	require.Equal(t, now.Add(time.Hour), s.total.newest)
+
+	children := s.sortedChildren()
+	require.Len(t, children, 2)
+	require.Equal(t, "data/x/", children[0].prefix)
+	require.Equal(t, int64(3), children[0].objects)
+	require.Equal(t, int64(1110), children[0].bytes)
+	require.Equal(t, "data/x/y/", children[1].prefix)
+	require.Equal(t, int64(2), children[1].objects)
+	require.Equal(t, int64(1100), children[1].bytes)
+
+	flat := newSummarizer("", 0)
+	flat.add(object("a/b", 1, now))
+	require.Empty(t, flat.sortedChildren())
+}
+
+// sliceObjects iterates over objects in memory.
+type sliceObjects struct {
+	objects []*uplink.Object
+	next    int
+}
+
+func (objects *sliceObjects) Next() bool {
+	if objects.next >= len(objects.objects) {
+		return false
+	}
+	objects.next++
+	return true
+}
+
+func (objects *sliceObjects) Item() *uplink.Object { return objects.objects[objects.next-1] }
+func (objects *sliceObjects) Err() error           { return nil }
+
+func TestSummarizerManifest(t *testing.T) {
+	uploadID, err := newUploadID()
+	require.NoError(t, err)
+
+	object := func(key string, size int64, custom uplink.CustomMetadata) *uplink.Object {
+		object := &uplink.Object{Key: key, Custom: custom}
+		object.System.ContentLength = size
+		return object
+	}
+
+	source := &sliceObjects{objects: []*uplink.Object{
+		object("data/a", 1, uplink.CustomMetadata{}),
+		object("data/big", 150, uplink.CustomMetadata{
+			manifestMetadataKey:     "1",
+			manifestSizeMetadataKey: "1000",
+		}),
+		object(partKey("data/big", uploadID, 0), 600, uplink.CustomMetadata{}),
+		object(partKey("data/big", uploadID, 1), 400, uplink.CustomMetadata{}),
+	}}
+
+	s := newSummarizer("data/", 0)
+	iterator := newFilteredObjects(context.Background(), nil, "bucket", source, nil, true, false)
+	require.NoError(t, s.walkObjects(iterator, nil, 0))
+
+	require.Equal(t, int64(2), s.total.objects)
+	require.Equal(t, int64(1001), s.total.bytes)
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, "alpha");
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    uint8_t data[1024];
+    fill_random_data(data, sizeof(data));
+
+    char *keys[] = {"a.bin", "missing.bin", "b.bin", "c.bin"};
+    size_t keys_count = 4;
+    for (size_t i = 0; i < keys_count; i++) {
+        if (strcmp(keys[i], "missing.bin") != 0) {
+            upload_data(project, "alpha", keys[i], data, (i + 1) * 100, NULL);
+        }
+    }
+
+    { // stat objects, with a missing key
+        Uplink_StatObjectsResult stat_result = uplink_stat_objects(project, "alpha", keys, keys_count);
+        require_noerror(stat_result.error);
+        require(stat_result.count == keys_count);
+
+        for (size_t i = 0; i < keys_count; i++) {
+            Uplink_ObjectResult result = stat_result.results[i];
+            if (strcmp(keys[i], "missing.bin") == 0) {
+                require_error(result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+                require(result.object == NULL);
+            } else {
+                require_noerror(result.error);
+                require(result.object != NULL);
+                require(strcmp(keys[i], result.object->key) == 0);
+                require(result.object->system.content_length == (i + 1) * 100);
+            }
+        }
+        uplink_free_stat_objects_result(stat_result);
+    }
+
+    { // stat no objects
+        Uplink_StatObjectsResult stat_result = uplink_stat_objects(project, "alpha", NULL, 0);
+        require_noerror(stat_result.error);
+        require(stat_result.count == 0);
+        uplink_free_stat_objects_result(stat_result);
+    }
+
+    { // delete objects, with a missing key
+        Uplink_DeleteObjectsOptions options = {concurrency : 2};
+        Uplink_DeleteObjectsResult delete_result = uplink_delete_objects(project, "alpha", keys, keys_count, &options);
+        require_noerror(delete_result.error);
+        require(delete_result.count == keys_count);
+
+        for (size_t i = 0; i < keys_count; i++) {
+            require(strcmp(keys[i], delete_result.results[i].key) == 0);
+            if (strcmp(keys[i], "missing.bin") == 0) {
+                require_error(delete_result.results[i].error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+            } else {
+                require_noerror(delete_result.results[i].error);
+            }
+        }
+        uplink_free_delete_objects_result(delete_result);
+
+        Uplink_ObjectIterator *it = uplink_list_objects(project, "alpha", NULL);
+        require(!uplink_object_iterator_next(it));
+        require_noerror(uplink_object_iterator_err(it));
+        uplink_free_object_iterator(it);
+    }
+
+    { // delete no objects
+        Uplink_DeleteObjectsResult delete_result = uplink_delete_objects(project, "alpha", NULL, 0, NULL);
+        require_noerror(delete_result.error);
+        require(delete_result.count == 0);
+        uplink_free_delete_objects_result(delete_result);
+    }
+
+    { // delete from a missing bucket
+        Uplink_DeleteObjectsResult delete_result = uplink_delete_objects(project, "missing", keys, keys_count, NULL);
+        require_noerror(delete_result.error);
+        require(delete_result.count == keys_count);
+        for (size_t i = 0; i < keys_count; i++) {
+            require(delete_result.results[i].error != NULL);
+        }
+        uplink_free_delete_objects_result(delete_result);
+    }
+}
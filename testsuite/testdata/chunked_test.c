@@ -0,0 +1,135 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, "alpha");
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    size_t data_len = 70 * 1024;
+    uint8_t *data = malloc(data_len);
+    fill_random_data(data, data_len);
+
+    { // upload in concurrent parts
+        Uplink_UploadOptions options = {
+            concurrency : 4,
+            part_size : 16 * 1024,
+        };
+        Uplink_UploadResult upload_result = uplink_upload_object(project, "alpha", "chunked.bin", &options);
+        require_noerror(upload_result.error);
+        require(upload_result.upload->_handle != 0);
+
+        size_t uploaded_total = 0;
+        while (uploaded_total < data_len) {
+            size_t size_to_write = (data_len - uploaded_total > 10000) ? 10000 : data_len - uploaded_total;
+
+            Uplink_WriteResult result = uplink_upload_write(upload_result.upload, (uint8_t *)data + uploaded_total, size_to_write);
+            require_noerror(result.error);
+            uploaded_total += result.bytes_written;
+            uplink_free_write_result(result);
+        }
+
+        Uplink_Error *commit_err = uplink_upload_commit(upload_result.upload);
+        require_noerror(commit_err);
+
+        uplink_free_upload_result(upload_result);
+    }
+
+    { // stat reports the uploaded size without the reserved metadata
+        Uplink_ObjectResult object_result = uplink_stat_object(project, "alpha", "chunked.bin");
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        require(object_result.object->system.content_length == data_len);
+        for (size_t i = 0; i < object_result.object->custom.count; i++) {
+            require(strncmp(object_result.object->custom.entries[i].key, "uplink-c:", 9) != 0);
+        }
+        uplink_free_object_result(object_result);
+    }
+
+    { // listing hides the parts
+        Uplink_ListObjectsOptions options = {
+            recursive : true,
+            system : true,
+        };
+        Uplink_ObjectIterator *it = uplink_list_objects(project, "alpha", &options);
+
+        int count = 0;
+        while (uplink_object_iterator_next(it)) {
+            Uplink_Object *object = uplink_object_iterator_item(it);
+            require(strcmp("chunked.bin", object->key) == 0);
+            require(object->system.content_length == data_len);
+            uplink_free_object(object);
+            count++;
+        }
+        require_noerror(uplink_object_iterator_err(it));
+        require(count == 1);
+        uplink_free_object_iterator(it);
+    }
+
+    { // listing non-recursively hides the parts prefix
+        Uplink_ObjectIterator *it = uplink_list_objects(project, "alpha", NULL);
+
+        int count = 0;
+        while (uplink_object_iterator_next(it)) {
+            Uplink_Object *object = uplink_object_iterator_item(it);
+            require(!object->is_prefix);
+            require(strcmp("chunked.bin", object->key) == 0);
+            uplink_free_object(object);
+            count++;
+        }
+        require_noerror(uplink_object_iterator_err(it));
+        require(count == 1);
+        uplink_free_object_iterator(it);
+    }
+
+    { // download reassembles the parts
+        require_data(project, "alpha", "chunked.bin", data, data_len);
+    }
+
+    { // download a range across parts
+        Uplink_DownloadOptions options = {
+            offset : 15 * 1024,
+            length : 20 * 1024,
+        };
+        uint8_t *downloaded = calloc(20 * 1024, 1);
+        Uplink_ReadResult result = uplink_download_into_buffer(project, "alpha", "chunked.bin", downloaded, 20 * 1024, &options);
+        require_noerror(result.error);
+        require(result.bytes_read == 20 * 1024);
+        require(memcmp(data + 15 * 1024, downloaded, 20 * 1024) == 0);
+        uplink_free_read_result(result);
+        free(downloaded);
+    }
+
+    { // delete removes the parts as well
+        Uplink_ObjectResult object_result = uplink_delete_object(project, "alpha", "chunked.bin");
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        require(object_result.object->system.content_length == data_len);
+        uplink_free_object_result(object_result);
+
+        Uplink_ObjectIterator *it = uplink_list_objects(project, "alpha", &(Uplink_ListObjectsOptions){recursive : true});
+        require(!uplink_object_iterator_next(it));
+        require_noerror(uplink_object_iterator_err(it));
+        uplink_free_object_iterator(it);
+    }
+
+    free(data);
+}
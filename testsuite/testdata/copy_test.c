@@ -0,0 +1,161 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    char *bucket_names[] = {"alpha", "beta"};
+    for (int i = 0; i < 2; i++) {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, bucket_names[i]);
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    size_t data_len = 30 * 1024;
+    uint8_t *data = malloc(data_len);
+    fill_random_data(data, data_len);
+
+    {
+        Uplink_UploadResult upload_result = uplink_upload_object(project, "alpha", "source.bin", NULL);
+        require_noerror(upload_result.error);
+
+        Uplink_CustomMetadataEntry entries[] = {
+            {key : "color", key_length : 5, value : "blue", value_length : 4},
+        };
+        Uplink_CustomMetadata custom = {entries : entries, count : 1};
+        require_noerror(uplink_upload_set_custom_metadata(upload_result.upload, custom));
+
+        Uplink_WriteResult write_result = uplink_upload_write(upload_result.upload, data, data_len);
+        require_noerror(write_result.error);
+        require(write_result.bytes_written == data_len);
+        uplink_free_write_result(write_result);
+
+        require_noerror(uplink_upload_commit(upload_result.upload));
+        uplink_free_upload_result(upload_result);
+    }
+
+    { // copy within the bucket keeps the metadata
+        Uplink_ObjectResult object_result = uplink_copy_object(project, "alpha", "source.bin", "alpha", "copy.bin", NULL);
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        require(strcmp("copy.bin", object_result.object->key) == 0);
+        require(object_result.object->system.content_length == data_len);
+        uplink_free_object_result(object_result);
+
+        Uplink_ObjectResult stat_result = uplink_stat_object(project, "alpha", "copy.bin");
+        require_noerror(stat_result.error);
+        require(custom_value(stat_result.object, "color") != NULL);
+        require(strcmp("blue", custom_value(stat_result.object, "color")) == 0);
+        uplink_free_object_result(stat_result);
+
+        require_data(project, "alpha", "copy.bin", data, data_len);
+    }
+
+    { // copy across buckets with replaced metadata
+        Uplink_CustomMetadataEntry entries[] = {
+            {key : "shape", key_length : 5, value : "round", value_length : 5},
+        };
+        Uplink_CustomMetadata custom = {entries : entries, count : 1};
+        Uplink_CopyObjectOptions options = {custom : &custom};
+
+        Uplink_ObjectResult object_result = uplink_copy_object(project, "alpha", "source.bin", "beta", "copy.bin", &options);
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        uplink_free_object_result(object_result);
+
+        Uplink_ObjectResult stat_result = uplink_stat_object(project, "beta", "copy.bin");
+        require_noerror(stat_result.error);
+        require(custom_value(stat_result.object, "color") == NULL);
+        require(custom_value(stat_result.object, "shape") != NULL);
+        require(strcmp("round", custom_value(stat_result.object, "shape")) == 0);
+        uplink_free_object_result(stat_result);
+
+        require_data(project, "beta", "copy.bin", data, data_len);
+    }
+
+    { // copy a compressed object with replaced metadata
+        Uplink_UploadOptions upload_options = {
+            compression : UPLINK_COMPRESSION_GZIP,
+            checksums : UPLINK_CHECKSUM_SHA256,
+        };
+        Uplink_UploadResult upload_result = uplink_upload_object(project, "alpha", "compressed.bin", &upload_options);
+        require_noerror(upload_result.error);
+
+        Uplink_WriteResult write_result = uplink_upload_write(upload_result.upload, data, data_len);
+        require_noerror(write_result.error);
+        uplink_free_write_result(write_result);
+
+        require_noerror(uplink_upload_commit(upload_result.upload));
+        uplink_free_upload_result(upload_result);
+
+        Uplink_CustomMetadataEntry entries[] = {
+            {key : "shape", key_length : 5, value : "round", value_length : 5},
+        };
+        Uplink_CustomMetadata custom = {entries : entries, count : 1};
+        Uplink_CopyObjectOptions options = {custom : &custom};
+
+        Uplink_ObjectResult object_result = uplink_copy_object(project, "alpha", "compressed.bin", "beta", "compressed.bin", &options);
+        require_noerror(object_result.error);
+        require(object_result.object->custom.count == 1);
+        require(strcmp("round", custom_value(object_result.object, "shape")) == 0);
+        uplink_free_object_result(object_result);
+
+        // the copy is still decompressed and verified on download
+        Uplink_DownloadResult download_result = uplink_download_object(project, "beta", "compressed.bin", NULL);
+        require_noerror(download_result.error);
+
+        uint8_t *downloaded = calloc(data_len + 1, 1);
+        size_t downloaded_total = 0;
+        while (true) {
+            Uplink_ReadResult result = uplink_download_read(download_result.download, downloaded + downloaded_total, data_len + 1 - downloaded_total);
+            downloaded_total += result.bytes_read;
+            if (result.error) {
+                require(result.error->code == EOF);
+                uplink_free_read_result(result);
+                break;
+            }
+            uplink_free_read_result(result);
+        }
+        require(downloaded_total == data_len);
+        require(memcmp(data, downloaded, data_len) == 0);
+        free(downloaded);
+
+        require_noerror(uplink_close_download(download_result.download));
+        uplink_free_download_result(download_result);
+    }
+
+    { // the source is kept
+        Uplink_ObjectResult stat_result = uplink_stat_object(project, "alpha", "source.bin");
+        require_noerror(stat_result.error);
+        uplink_free_object_result(stat_result);
+    }
+
+    { // copy a missing object
+        Uplink_ObjectResult object_result = uplink_copy_object(project, "alpha", "missing.bin", "alpha", "copy2.bin", NULL);
+        require_error(object_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+        require(object_result.object == NULL);
+        uplink_free_object_result(object_result);
+    }
+
+    { // invalid arguments
+        Uplink_ObjectResult object_result = uplink_copy_object(project, "alpha", NULL, "alpha", "copy2.bin", NULL);
+        require(object_result.error != NULL);
+        uplink_free_object_result(object_result);
+    }
+
+    free(data);
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void write_file(const char *path, uint8_t *data, size_t length)
+{
+    FILE *file = fopen(path, "wb");
+    require(file != NULL);
+    require(fwrite(data, 1, length, file) == length);
+    require(fclose(file) == 0);
+}
+
+size_t read_file(const char *path, uint8_t *data, size_t length)
+{
+    FILE *file = fopen(path, "rb");
+    require(file != NULL);
+    size_t n = fread(data, 1, length, file);
+    require(fclose(file) == 0);
+    return n;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, "alpha");
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    char upload_path[1024], download_path[1024];
+    snprintf(upload_path, sizeof(upload_path), "%s/upload.bin", getenv("TMP_DIR"));
+    snprintf(download_path, sizeof(download_path), "%s/download.bin", getenv("TMP_DIR"));
+
+    size_t data_len = 100 * 1024;
+    uint8_t *data = malloc(data_len);
+    fill_random_data(data, data_len);
+    write_file(upload_path, data, data_len);
+
+    { // upload from a file
+        Uplink_ObjectResult object_result = uplink_upload_file(project, "alpha", "file.bin", upload_path, NULL);
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        require(strcmp("file.bin", object_result.object->key) == 0);
+        require(object_result.object->system.content_length == data_len);
+        uplink_free_object_result(object_result);
+    }
+
+    { // upload from a missing file
+        Uplink_ObjectResult object_result = uplink_upload_file(project, "alpha", "missing.bin", "/nonexistent/file", NULL);
+        require(object_result.error != NULL);
+        require(object_result.object == NULL);
+        uplink_free_object_result(object_result);
+    }
+
+    { // download into a file
+        Uplink_ObjectResult object_result = uplink_download_to_file(project, "alpha", "file.bin", download_path, NULL);
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        require(object_result.object->system.content_length == data_len);
+        uplink_free_object_result(object_result);
+
+        uint8_t *downloaded = malloc(data_len * 2);
+        require(read_file(download_path, downloaded, data_len * 2) == data_len);
+        require(memcmp(data, downloaded, data_len) == 0);
+        free(downloaded);
+    }
+
+    { // download a range in parallel parts into a file
+        Uplink_DownloadOptions options = {
+            offset : 1000,
+            length : 50 * 1024,
+            concurrency : 4,
+            part_size : 8 * 1024,
+        };
+        Uplink_ObjectResult object_result = uplink_download_to_file(project, "alpha", "file.bin", download_path, &options);
+        require_noerror(object_result.error);
+        uplink_free_object_result(object_result);
+
+        uint8_t *downloaded = malloc(data_len);
+        require(read_file(download_path, downloaded, data_len) == 50 * 1024);
+        require(memcmp(data + 1000, downloaded, 50 * 1024) == 0);
+        free(downloaded);
+    }
+
+    { // download a missing object
+        Uplink_ObjectResult object_result = uplink_download_to_file(project, "alpha", "missing.bin", download_path, NULL);
+        require_error(object_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+        require(object_result.object == NULL);
+        uplink_free_object_result(object_result);
+    }
+
+    {
+        Uplink_ObjectResult object_result = uplink_delete_object(project, "alpha", "file.bin");
+        require_noerror(object_result.error);
+        uplink_free_object_result(object_result);
+    }
+
+    free(data);
+}
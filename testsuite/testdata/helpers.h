@@ -4,13 +4,14 @@
 #pragma once
 
 #include <stdlib.h>
+#include <string.h>
 #include <time.h>
 
 #include "require.h"
 #include "uplink.h"
 
 // with_test_project opens default test project and calls handleProject callback.
-void with_test_project(void (*handleProject)(Project *))
+void with_test_project(void (*handleProject)(Uplink_Project *))
 {
     // disable buffering
     setvbuf(stdout, NULL, _IONBF, 0);
@@ -23,25 +24,25 @@ void with_test_project(void (*handleProject)(Project *))
     printf("using SATELLITE_0_ADDR: %s\n", satellite_addr);
     printf("using UPLINK_0_ACCESS: %s\n", access_string);
 
-    AccessResult access_result = request_access_with_passphrase(satellite_addr, api_key, "mypassphrase");
+    Uplink_AccessResult access_result = uplink_request_access_with_passphrase(satellite_addr, api_key, "mypassphrase");
     require_noerror(access_result.error);
 
-    ProjectResult project_result = open_project(access_result.access);
+    Uplink_ProjectResult project_result = uplink_open_project(access_result.access);
     require_noerror(project_result.error);
     requiref(project_result.project->_handle != 0, "got empty project\n");
 
-    free_access_result(access_result);
+    uplink_free_access_result(access_result);
 
     {
         handleProject(project_result.project);
     }
 
-    Error *close_err = close_project(project_result.project);
+    Uplink_Error *close_err = uplink_close_project(project_result.project);
     require_noerror(close_err);
 
-    free_project_result(project_result);
+    uplink_free_project_result(project_result);
 
-    requiref(internal_UniverseIsEmpty(), "universe is not empty\n");
+    requiref(uplink_internal_UniverseIsEmpty(), "universe is not empty\n");
 }
 
 void fill_random_data(uint8_t *buffer, size_t length)
@@ -51,6 +52,52 @@ void fill_random_data(uint8_t *buffer, size_t length)
     }
 }
 
+// upload_data uploads data to the object at object_key and commits it.
+void upload_data(Uplink_Project *project, char *bucket_name, char *object_key, uint8_t *data, size_t data_len, Uplink_UploadOptions *options)
+{
+    Uplink_UploadResult upload_result = uplink_upload_object(project, bucket_name, object_key, options);
+    require_noerror(upload_result.error);
+
+    Uplink_WriteResult write_result = uplink_upload_write(upload_result.upload, data, data_len);
+    require_noerror(write_result.error);
+    require(write_result.bytes_written == data_len);
+    uplink_free_write_result(write_result);
+
+    require_noerror(uplink_upload_commit(upload_result.upload));
+    uplink_free_upload_result(upload_result);
+}
+
+// require_data requires the object at object_key to contain exactly data.
+void require_data(Uplink_Project *project, char *bucket_name, char *object_key, uint8_t *data, size_t data_len)
+{
+    uint8_t *downloaded = calloc(data_len, 1);
+    Uplink_ReadResult result = uplink_download_into_buffer(project, bucket_name, object_key, downloaded, data_len, NULL);
+    require_noerror(result.error);
+    require(result.bytes_read == data_len);
+    require(memcmp(data, downloaded, data_len) == 0);
+    uplink_free_read_result(result);
+    free(downloaded);
+}
+
+// require_missing requires the object at object_key to not exist.
+void require_missing(Uplink_Project *project, char *bucket_name, char *object_key)
+{
+    Uplink_ObjectResult object_result = uplink_stat_object(project, bucket_name, object_key);
+    require_error(object_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+    uplink_free_object_result(object_result);
+}
+
+// custom_value returns the value of key in the custom metadata of object or NULL.
+const char *custom_value(Uplink_Object *object, const char *key)
+{
+    for (size_t i = 0; i < object->custom.count; i++) {
+        if (strcmp(object->custom.entries[i].key, key) == 0) {
+            return object->custom.entries[i].value;
+        }
+    }
+    return NULL;
+}
+
 bool array_contains(char *item, char *array[], int array_size)
 {
     for (int i = 0; i < array_size; i++) {
@@ -0,0 +1,92 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    char *bucket_names[] = {"alpha", "beta"};
+    for (int i = 0; i < 2; i++) {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, bucket_names[i]);
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    size_t data_len = 20 * 1024;
+    uint8_t *data = malloc(data_len);
+    fill_random_data(data, data_len);
+
+    { // move within the bucket
+        upload_data(project, "alpha", "old.bin", data, data_len, NULL);
+
+        Uplink_ObjectResult object_result = uplink_move_object(project, "alpha", "old.bin", "alpha", "new.bin");
+        require_noerror(object_result.error);
+        require(object_result.object != NULL);
+        require(strcmp("new.bin", object_result.object->key) == 0);
+        require(object_result.object->system.content_length == data_len);
+        uplink_free_object_result(object_result);
+
+        require_missing(project, "alpha", "old.bin");
+        require_data(project, "alpha", "new.bin", data, data_len);
+    }
+
+    { // move across buckets
+        Uplink_ObjectResult object_result = uplink_move_object(project, "alpha", "new.bin", "beta", "moved.bin");
+        require_noerror(object_result.error);
+        uplink_free_object_result(object_result);
+
+        require_missing(project, "alpha", "new.bin");
+        require_data(project, "beta", "moved.bin", data, data_len);
+    }
+
+    { // move a missing object
+        Uplink_ObjectResult object_result = uplink_move_object(project, "alpha", "missing.bin", "alpha", "other.bin");
+        require_error(object_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+        require(object_result.object == NULL);
+        uplink_free_object_result(object_result);
+
+        require_missing(project, "alpha", "other.bin");
+    }
+
+    { // move a prefix
+        char *keys[] = {"dir/a.bin", "dir/b.bin", "dir/sub/c.bin"};
+        for (int i = 0; i < 3; i++) {
+            upload_data(project, "alpha", keys[i], data, data_len, NULL);
+        }
+        upload_data(project, "alpha", "other/d.bin", data, data_len, NULL);
+
+        Uplink_MovePrefixResult move_result = uplink_move_prefix(project, "alpha", "dir/", "beta", "renamed/");
+        require_noerror(move_result.error);
+        require(move_result.moved == 3);
+        uplink_free_move_prefix_result(move_result);
+
+        char *moved_keys[] = {"renamed/a.bin", "renamed/b.bin", "renamed/sub/c.bin"};
+        for (int i = 0; i < 3; i++) {
+            require_missing(project, "alpha", keys[i]);
+            require_data(project, "beta", moved_keys[i], data, data_len);
+        }
+        require_data(project, "alpha", "other/d.bin", data, data_len);
+    }
+
+    { // move an empty prefix
+        Uplink_MovePrefixResult move_result = uplink_move_prefix(project, "alpha", "empty/", "beta", "renamed/");
+        require_noerror(move_result.error);
+        require(move_result.moved == 0);
+        uplink_free_move_prefix_result(move_result);
+    }
+
+    free(data);
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, "alpha");
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    uint8_t data[1024];
+    fill_random_data(data, sizeof(data));
+
+    char *object_keys[] = {"a.txt", "b.bin", "c.txt", "d.bin", "e.txt", "f.bin", "g.txt"};
+    size_t object_count = 7;
+    for (size_t i = 0; i < object_count; i++) {
+        upload_data(project, "alpha", object_keys[i], data, (i + 1) * 100, NULL);
+    }
+
+    { // list all objects in pages
+        char *cursor = NULL;
+        size_t count = 0;
+        int pages = 0;
+        bool more = true;
+        while (more) {
+            Uplink_ListObjectsOptions options = {
+                cursor : cursor,
+                system : true,
+            };
+            Uplink_ObjectPageResult page = uplink_list_objects_page(project, "alpha", &options, 3);
+            require_noerror(page.error);
+            require(page.count <= 3);
+
+            for (size_t i = 0; i < page.count; i++) {
+                require(count + i < object_count);
+                require(strcmp(object_keys[count + i], page.objects[i].key) == 0);
+                require(page.objects[i].system.content_length == (count + i + 1) * 100);
+            }
+            count += page.count;
+            pages++;
+
+            more = page.more;
+            free(cursor);
+            cursor = more ? strdup(page.cursor) : NULL;
+            uplink_free_object_page_result(page);
+        }
+        require(count == object_count);
+        require(pages == 3);
+    }
+
+    { // list filtered objects in pages
+        const char *expected[] = {"a.txt", "c.txt", "e.txt", "g.txt"};
+        char *cursor = NULL;
+        size_t count = 0;
+        bool more = true;
+        while (more) {
+            Uplink_ListObjectsOptions options = {
+                cursor : cursor,
+                glob : "*.txt",
+            };
+            Uplink_ObjectPageResult page = uplink_list_objects_page(project, "alpha", &options, 2);
+            require_noerror(page.error);
+            require(page.count <= 2);
+
+            for (size_t i = 0; i < page.count; i++) {
+                require(count + i < 4);
+                require(strcmp(expected[count + i], page.objects[i].key) == 0);
+            }
+            count += page.count;
+
+            more = page.more;
+            free(cursor);
+            cursor = more ? strdup(page.cursor) : NULL;
+            uplink_free_object_page_result(page);
+        }
+        require(count == 4);
+    }
+
+    { // a page larger than the listing
+        Uplink_ObjectPageResult page = uplink_list_objects_page(project, "alpha", NULL, 100);
+        require_noerror(page.error);
+        require(page.count == object_count);
+        require(!page.more);
+        uplink_free_object_page_result(page);
+    }
+
+    { // invalid page size
+        Uplink_ObjectPageResult page = uplink_list_objects_page(project, "alpha", NULL, 0);
+        require(page.error != NULL);
+        require(page.objects == NULL);
+        uplink_free_object_page_result(page);
+    }
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void write_all(Uplink_Upload *upload, uint8_t *data, size_t length)
+{
+    size_t uploaded_total = 0;
+    while (uploaded_total < length) {
+        size_t size_to_write = (length - uploaded_total > 10000) ? 10000 : length - uploaded_total;
+
+        Uplink_WriteResult result = uplink_upload_write(upload, data + uploaded_total, size_to_write);
+        require_noerror(result.error);
+        uploaded_total += result.bytes_written;
+        uplink_free_write_result(result);
+    }
+}
+
+void handle_project(Uplink_Project *project)
+{
+    {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, "alpha");
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    size_t part_size = 16 * 1024;
+    size_t data_len = 100 * 1024;
+    uint8_t *data = malloc(data_len);
+    fill_random_data(data, data_len);
+
+    { // resuming without an interrupted upload fails
+        Uplink_ResumeUploadResult resume_result = uplink_resume_upload(project, "alpha", "resumed.bin", NULL);
+        require_error(resume_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+        require(resume_result.upload == NULL);
+        uplink_free_resume_upload_result(resume_result);
+    }
+
+    { // interrupt a resumable upload
+        Uplink_UploadOptions options = {
+            concurrency : 2,
+            part_size : part_size,
+            resumable : true,
+            checksums : UPLINK_CHECKSUM_SHA256,
+        };
+        Uplink_UploadResult upload_result = uplink_upload_object(project, "alpha", "resumed.bin", &options);
+        require_noerror(upload_result.error);
+
+        write_all(upload_result.upload, data, 40 * 1024);
+
+        // freeing the upload without commit keeps the uploaded parts
+        uplink_free_upload_result(upload_result);
+    }
+
+    { // continue the upload from the returned offset
+        Uplink_ResumeUploadResult resume_result = uplink_resume_upload(project, "alpha", "resumed.bin", NULL);
+        require_noerror(resume_result.error);
+        require(resume_result.upload != NULL);
+        require(resume_result.offset >= 0);
+        require(resume_result.offset <= 2 * part_size);
+        require(resume_result.offset % part_size == 0);
+
+        write_all(resume_result.upload, data + resume_result.offset, data_len - resume_result.offset);
+
+        Uplink_Error *commit_err = uplink_upload_commit(resume_result.upload);
+        require_noerror(commit_err);
+
+        uplink_free_resume_upload_result(resume_result);
+    }
+
+    { // the committed object contains all of the data
+        Uplink_ObjectResult object_result = uplink_stat_object(project, "alpha", "resumed.bin");
+        require_noerror(object_result.error);
+        require(object_result.object->system.content_length == data_len);
+        uplink_free_object_result(object_result);
+
+        // the checksums requested by the interrupted upload are verified
+        require_data(project, "alpha", "resumed.bin", data, data_len);
+    }
+
+    { // a committed upload cannot be resumed
+        Uplink_ResumeUploadResult resume_result = uplink_resume_upload(project, "alpha", "resumed.bin", NULL);
+        require_error(resume_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+        uplink_free_resume_upload_result(resume_result);
+    }
+
+    { // an aborted upload cannot be resumed
+        Uplink_UploadOptions options = {
+            part_size : part_size,
+            resumable : true,
+        };
+        Uplink_UploadResult upload_result = uplink_upload_object(project, "alpha", "aborted.bin", &options);
+        require_noerror(upload_result.error);
+
+        write_all(upload_result.upload, data, 40 * 1024);
+
+        Uplink_Error *abort_err = uplink_upload_abort(upload_result.upload);
+        require_noerror(abort_err);
+        uplink_free_upload_result(upload_result);
+
+        Uplink_ResumeUploadResult resume_result = uplink_resume_upload(project, "alpha", "aborted.bin", NULL);
+        require_error(resume_result.error, UPLINK_ERROR_OBJECT_NOT_FOUND);
+        uplink_free_resume_upload_result(resume_result);
+
+        Uplink_ObjectIterator *it = uplink_list_objects(project, "alpha", &(Uplink_ListObjectsOptions){prefix : "aborted.bin", recursive : true});
+        require(!uplink_object_iterator_next(it));
+        require_noerror(uplink_object_iterator_err(it));
+        uplink_free_object_iterator(it);
+    }
+
+    { // restarting an interrupted upload deletes its parts
+        Uplink_UploadOptions options = {
+            part_size : part_size,
+            resumable : true,
+        };
+        Uplink_UploadResult upload_result = uplink_upload_object(project, "alpha", "restarted.bin", &options);
+        require_noerror(upload_result.error);
+        write_all(upload_result.upload, data, 40 * 1024);
+        uplink_free_upload_result(upload_result);
+
+        upload_result = uplink_upload_object(project, "alpha", "restarted.bin", &options);
+        require_noerror(upload_result.error);
+        require_noerror(uplink_upload_abort(upload_result.upload));
+        uplink_free_upload_result(upload_result);
+
+        Uplink_ObjectIterator *it = uplink_list_objects(project, "alpha", &(Uplink_ListObjectsOptions){prefix : "restarted.bin", recursive : true});
+        require(!uplink_object_iterator_next(it));
+        require_noerror(uplink_object_iterator_err(it));
+        uplink_free_object_iterator(it);
+    }
+
+    {
+        Uplink_ObjectResult object_result = uplink_delete_object(project, "alpha", "resumed.bin");
+        require_noerror(object_result.error);
+        uplink_free_object_result(object_result);
+    }
+
+    free(data);
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2020 Storj Labs, Inc.
+// See LICENSE for copying information.
+
+#include <stdlib.h>
+#include <string.h>
+#include <time.h>
+
+#include "helpers.h"
+#include "require.h"
+#include "uplink.h"
+
+void handle_project(Uplink_Project *project);
+
+int main(int argc, char *argv[])
+{
+    with_test_project(&handle_project);
+    return 0;
+}
+
+void on_progress(int64_t objects, int64_t bytes, void *user_data)
+{
+    int64_t *last_objects = user_data;
+    require(objects >= *last_objects);
+    *last_objects = objects;
+}
+
+void handle_project(Uplink_Project *project)
+{
+    {
+        Uplink_BucketResult bucket_result = uplink_ensure_bucket(project, "alpha");
+        require_noerror(bucket_result.error);
+        uplink_free_bucket_result(bucket_result);
+    }
+
+    int64_t current_time = (int64_t)time(NULL);
+
+    uint8_t data[4096];
+    fill_random_data(data, sizeof(data));
+
+    upload_data(project, "alpha", "data/a", data, 100, NULL);
+    upload_data(project, "alpha", "data/x/b", data, 200, NULL);
+    upload_data(project, "alpha", "data/x/y/c", data, 300, NULL);
+    upload_data(project, "alpha", "other/d", data, 400, NULL);
+
+    // an object uploaded in parts is counted once with its uploaded size
+    Uplink_UploadOptions chunked = {
+        concurrency : 2,
+        part_size : 1024,
+    };
+    upload_data(project, "alpha", "data/big", data, 3000, &chunked);
+
+    { // summarize the whole bucket
+        Uplink_SummarizePrefixResult result = uplink_summarize_prefix(project, "alpha", "", NULL);
+        require_noerror(result.error);
+        require(result.summary.objects == 5);
+        require(result.summary.bytes == 4000);
+        require(result.summary.oldest_created >= current_time - 60);
+        require(result.summary.newest_created >= result.summary.oldest_created);
+        require(result.children_count == 0);
+        uplink_free_summarize_prefix_result(result);
+    }
+
+    { // summarize a prefix with nested prefixes
+        int64_t last_objects = 0;
+        Uplink_SummarizePrefixOptions options = {
+            depth : 2,
+            progress : on_progress,
+            progress_user_data : &last_objects,
+        };
+        Uplink_SummarizePrefixResult result = uplink_summarize_prefix(project, "alpha", "data/", &options);
+        require_noerror(result.error);
+        require(strcmp("data/", result.summary.prefix) == 0);
+        require(result.summary.objects == 4);
+        require(result.summary.bytes == 3600);
+        require(last_objects == 4);
+
+        require(result.children_count == 2);
+        require(strcmp("data/x/", result.children[0].prefix) == 0);
+        require(result.children[0].objects == 2);
+        require(result.children[0].bytes == 500);
+        require(strcmp("data/x/y/", result.children[1].prefix) == 0);
+        require(result.children[1].objects == 1);
+        require(result.children[1].bytes == 300);
+        uplink_free_summarize_prefix_result(result);
+    }
+
+    { // summarize an empty prefix
+        Uplink_SummarizePrefixResult result = uplink_summarize_prefix(project, "alpha", "missing/", NULL);
+        require_noerror(result.error);
+        require(result.summary.objects == 0);
+        require(result.summary.bytes == 0);
+        require(result.summary.oldest_created == 0);
+        require(result.summary.newest_created == 0);
+        uplink_free_summarize_prefix_result(result);
+    }
+
+    { // summarize a missing bucket
+        Uplink_SummarizePrefixResult result = uplink_summarize_prefix(project, "missing", "", NULL);
+        require(result.error != NULL);
+        uplink_free_summarize_prefix_result(result);
+    }
+}
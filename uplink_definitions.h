@@ -29,6 +29,22 @@ typedef struct Uplink_EncryptionKey {
     size_t _handle;
 } Uplink_EncryptionKey;
 
+typedef struct Uplink_CancelToken {
+    size_t _handle;
+} Uplink_CancelToken;
+
+typedef struct Uplink_CompletionQueue {
+    size_t _handle;
+} Uplink_CompletionQueue;
+
+typedef struct Uplink_ObjectReader {
+    size_t _handle;
+} Uplink_ObjectReader;
+
+typedef struct Uplink_BandwidthLimiter {
+    size_t _handle;
+} Uplink_BandwidthLimiter;
+
 typedef struct Uplink_Config {
     const char *user_agent;
 
@@ -36,11 +52,20 @@ typedef struct Uplink_Config {
 
     // temp_directory specifies where to save data during downloads to use less memory.
     const char *temp_directory;
+
+    // bandwidth_limiter limits the transfer rates of all projects opened with it.
+    // When NULL, each project is unlimited until uplink_project_set_bandwidth_limits.
+    Uplink_BandwidthLimiter *bandwidth_limiter;
 } Uplink_Config;
 
 typedef struct Uplink_Bucket {
     const char *name;
     int64_t created;
+
+    // has_stats is true when object_count and total_bytes are populated.
+    bool has_stats;
+    int64_t object_count;
+    int64_t total_bytes;
 } Uplink_Bucket;
 
 typedef struct Uplink_SystemMetadata {
@@ -69,17 +94,152 @@ typedef struct Uplink_Object {
     Uplink_CustomMetadata custom;
 } Uplink_Object;
 
+// Uplink_ProgressCallback reports the number of bytes transferred so far.
+// total_bytes is -1 when the size of the transfer is not known in advance.
+typedef void (*Uplink_ProgressCallback)(int64_t bytes_transferred, int64_t total_bytes, int64_t elapsed_milliseconds, void *user_data);
+typedef void (*Uplink_SummaryProgressCallback)(int64_t objects, int64_t bytes, void *user_data);
+
+enum {
+    UPLINK_CHECKSUM_SHA256 = 0x01,
+    UPLINK_CHECKSUM_MD5 = 0x02,
+    UPLINK_CHECKSUM_CRC32C = 0x04
+};
+
+// Uplink_CompressionCodec selects the codec of compressed uploads. Further codecs
+// are added as new values, unsupported values fail with UPLINK_ERROR_UNSUPPORTED.
+typedef enum Uplink_CompressionCodec {
+    UPLINK_COMPRESSION_NONE = 0x00,
+    UPLINK_COMPRESSION_GZIP = 0x01
+} Uplink_CompressionCodec;
+
 typedef struct Uplink_UploadOptions {
     // When expires is 0 or negative, it means no expiration.
     int64_t expires;
+
+    // progress is invoked while data is uploaded, when it is not NULL.
+    Uplink_ProgressCallback progress;
+    void *progress_user_data;
+    // When progress_interval_milliseconds is 0 or negative, progress is invoked after every write.
+    int64_t progress_interval_milliseconds;
+
+    // concurrency is the number of parts uploaded in parallel. When it is larger than 1,
+    // the data is split into parts of part_size bytes, which are uploaded concurrently as
+    // separate objects, and a manifest of the parts is committed at the key. Downloads
+    // reassemble such objects transparently.
+    int32_t concurrency;
+    // part_size is the size of a part in bytes. When 0, it uses 16 MiB.
+    int64_t part_size;
+
+    // resumable uploads the data in parts and records the committed parts in a journal
+    // in the temp_directory of the config. When the upload is interrupted, it can be
+    // continued with uplink_resume_upload, also after the process restarts, by a project
+    // opened with the same access. Starting another resumable upload to the same key
+    // deletes the parts of the interrupted one.
+    bool resumable;
+
+    // checksums is a combination of UPLINK_CHECKSUM_* flags. The enabled checksums are
+    // computed from the written data and stored hex encoded in the custom metadata under
+    // the reserved keys "uplink-c:checksum:<algorithm>" on commit. Full downloads of the
+    // object verify them and fail with UPLINK_ERROR_CHECKSUM_MISMATCH.
+    // Uploads continued with uplink_resume_upload compute the checksums of the interrupted
+    // upload, for which the already uploaded parts are downloaded again.
+    uint32_t checksums;
+
+    // bytes_per_second overrides the upload rate limit of the project when positive.
+    // When negative the upload is not limited, when 0 the project limit applies.
+    int64_t bytes_per_second;
+
+    // compression is a Uplink_CompressionCodec. Compressed data is uploaded and the codec
+    // is recorded in the custom metadata under the reserved key "uplink-c:compression".
+    // Downloads decompress such objects transparently. Resumable uploads cannot be compressed.
+    uint32_t compression;
 } Uplink_UploadOptions;
 
 typedef struct Uplink_DownloadOptions {
     int64_t offset;
     // When length is negative, it will read until the end of the blob.
     int64_t length;
+
+    // progress is invoked while data is downloaded, when it is not NULL.
+    Uplink_ProgressCallback progress;
+    void *progress_user_data;
+    // When progress_interval_milliseconds is 0 or negative, progress is invoked after every read.
+    int64_t progress_interval_milliseconds;
+
+    // max_retries is how many times a failed read is retried by reopening the object at
+    // the current offset. When the object has changed in between, the read fails with
+    // UPLINK_ERROR_OBJECT_CHANGED. Retrying is disabled when 0.
+    int32_t max_retries;
+    // retry_backoff_milliseconds is the delay before the first retry, it doubles for every
+    // consecutive retry.
+    int64_t retry_backoff_milliseconds;
+
+    // concurrency is the number of parts downloaded in parallel. When it is larger
+    // than 1, the object is split into parts of part_size bytes, which are downloaded
+    // concurrently and returned in order.
+    int32_t concurrency;
+    // part_size is the size of a part in bytes. When 0, it uses 16 MiB.
+    int64_t part_size;
+
+    // bytes_per_second overrides the download rate limit of the project when positive.
+    // When negative the download is not limited, when 0 the project limit applies.
+    int64_t bytes_per_second;
+
+    // raw returns the stored bytes of compressed objects without decompressing them.
+    // Ranges of compressed objects can only be downloaded raw, otherwise the download
+    // fails with UPLINK_ERROR_UNSUPPORTED. Checksums are not verified for raw downloads.
+    bool raw;
 } Uplink_DownloadOptions;
 
+typedef struct Uplink_CopyObjectOptions {
+    // expires overrides the expiration of the source object when positive, in unix time seconds.
+    // When negative the copy does not expire, when 0 the expiration of the source is kept.
+    int64_t expires;
+    // custom replaces the custom metadata of the source object when it is not NULL.
+    // Reserved keys, which start with "uplink-c:", are always kept from the source.
+    Uplink_CustomMetadata *custom;
+} Uplink_CopyObjectOptions;
+
+typedef struct Uplink_UpdateObjectMetadataOptions {
+    // merge adds the new metadata to the existing metadata instead of replacing it.
+    bool merge;
+} Uplink_UpdateObjectMetadataOptions;
+
+typedef struct Uplink_DeletePrefixOptions {
+    // concurrency is the number of objects deleted in parallel. When 0, it uses 8.
+    int32_t concurrency;
+} Uplink_DeletePrefixOptions;
+
+typedef struct Uplink_DeleteObjectsOptions {
+    // concurrency is the number of objects deleted in parallel. When 0, it uses 8.
+    int32_t concurrency;
+} Uplink_DeleteObjectsOptions;
+
+typedef struct Uplink_DeleteBucketOptions {
+    // force deletes all objects in the bucket before deleting the bucket.
+    bool force;
+    // concurrency is the number of objects deleted in parallel. When 0, it uses 8.
+    int32_t concurrency;
+} Uplink_DeleteBucketOptions;
+
+typedef struct Uplink_SummarizePrefixOptions {
+    // depth is how many levels of nested prefixes are summarized separately.
+    // When 0, only the total is returned.
+    int32_t depth;
+
+    // progress is invoked with the objects and bytes counted so far, when it is not NULL.
+    Uplink_SummaryProgressCallback progress;
+    void *progress_user_data;
+    // When progress_interval_milliseconds is 0 or negative, progress is invoked after every object.
+    int64_t progress_interval_milliseconds;
+} Uplink_SummarizePrefixOptions;
+
+typedef struct Uplink_ObjectReaderOptions {
+    // read_ahead is the number of bytes fetched at once for small reads.
+    // When 0, it uses 256 KiB.
+    size_t read_ahead;
+} Uplink_ObjectReaderOptions;
+
 typedef struct Uplink_ListObjectsOptions {
     const char *prefix;
     const char *cursor;
@@ -87,10 +247,55 @@ typedef struct Uplink_ListObjectsOptions {
 
     bool system;
     bool custom;
+
+    // The following filters are applied on the client, only matching objects are returned.
+    // Filters on object metadata include the needed metadata in the results and never
+    // match prefixes.
+
+    // glob is a pattern as in path.Match the key must match, ignored when NULL.
+    const char *glob;
+    // regex is a regular expression the key must match, ignored when NULL.
+    const char *regex;
+    // min_content_length and max_content_length limit the size, ignored when 0.
+    int64_t min_content_length;
+    int64_t max_content_length;
+    // created_after and created_before limit the creation time in unix time seconds, ignored when 0.
+    int64_t created_after;
+    int64_t created_before;
+    // expires_before only matches objects expiring before the unix time in seconds, ignored when 0.
+    int64_t expires_before;
+    // metadata_key must be present in the custom metadata, ignored when NULL.
+    const char *metadata_key;
+    // metadata_value is the value metadata_key must have, any value matches when NULL.
+    const char *metadata_value;
 } Uplink_ListObjectsOptions;
 
+typedef struct Uplink_BucketStatsOptions {
+    // concurrency is the number of buckets walked at the same time.
+    // When 0, 4 buckets are walked concurrently.
+    int32_t concurrency;
+
+    // ttl_milliseconds is how long the statistics of a bucket are reused.
+    // When 0, they are reused for a minute. When negative, they are not cached.
+    int64_t ttl_milliseconds;
+} Uplink_BucketStatsOptions;
+
 typedef struct Uplink_ListBucketsOptions {
     const char *cursor;
+
+    // The following filters are applied on the client, only matching buckets are returned.
+
+    // prefix is what the bucket name must start with, ignored when NULL. The listing
+    // starts at the prefix and stops after the last matching bucket.
+    const char *prefix;
+    // glob is a pattern as in path.Match the bucket name must match, ignored when NULL.
+    const char *glob;
+    // created_after and created_before limit the creation time in unix time seconds, ignored when 0.
+    int64_t created_after;
+    int64_t created_before;
+
+    // stats populates the statistics of every listed bucket, when it is not NULL.
+    Uplink_BucketStatsOptions *stats;
 } Uplink_ListBucketsOptions;
 
 typedef struct Uplink_ObjectIterator {
@@ -131,6 +336,8 @@ enum {
     UPLINK_ERROR_INVALID_HANDLE = 0x04,
     UPLINK_ERROR_TOO_MANY_REQUESTS = 0x05,
     UPLINK_ERROR_BANDWIDTH_LIMIT_EXCEEDED = 0x06,
+    UPLINK_ERROR_DEADLINE_EXCEEDED = 0x07,
+    UPLINK_ERROR_UNSUPPORTED = 0x08,
 
     UPLINK_ERROR_BUCKET_NAME_INVALID = 0x10,
     UPLINK_ERROR_BUCKET_ALREADY_EXISTS = 0x11,
@@ -139,9 +346,32 @@ enum {
 
     UPLINK_ERROR_OBJECT_KEY_INVALID = 0x20,
     UPLINK_ERROR_OBJECT_NOT_FOUND = 0x21,
-    UPLINK_ERROR_UPLOAD_DONE = 0x22
+    UPLINK_ERROR_UPLOAD_DONE = 0x22,
+    UPLINK_ERROR_OBJECT_CHANGED = 0x23,
+    UPLINK_ERROR_CHECKSUM_MISMATCH = 0x24,
+    UPLINK_ERROR_MOVE_INCOMPLETE = 0x25
 };
 
+enum {
+    UPLINK_COMPLETION_READ = 0x01,
+    UPLINK_COMPLETION_WRITE = 0x02,
+    UPLINK_COMPLETION_COMMIT = 0x03,
+    UPLINK_COMPLETION_NEXT = 0x04
+};
+
+typedef struct Uplink_Completion {
+    // kind is one of UPLINK_COMPLETION_* and identifies the finished operation.
+    int32_t kind;
+    void *user_data;
+
+    // bytes is the number of bytes read or written.
+    size_t bytes;
+    // has_next is the result of advancing an iterator.
+    bool has_next;
+
+    Uplink_Error *error;
+} Uplink_Completion;
+
 typedef struct Uplink_AccessResult {
     Uplink_Access *access;
     Uplink_Error *error;
@@ -162,16 +392,128 @@ typedef struct Uplink_ObjectResult {
     Uplink_Error *error;
 } Uplink_ObjectResult;
 
+typedef struct Uplink_ObjectPageResult {
+    // objects and all data they refer to are a single allocation.
+    Uplink_Object *objects;
+    size_t count;
+    // cursor continues the listing when passed in Uplink_ListObjectsOptions.
+    const char *cursor;
+    // more is true when there are objects after the page.
+    bool more;
+    Uplink_Error *error;
+} Uplink_ObjectPageResult;
+
+typedef struct Uplink_BucketPageResult {
+    // buckets and all data they refer to are a single allocation.
+    Uplink_Bucket *buckets;
+    size_t count;
+    // cursor continues the listing when passed in Uplink_ListBucketsOptions.
+    const char *cursor;
+    // more is true when there are buckets after the page.
+    bool more;
+    Uplink_Error *error;
+} Uplink_BucketPageResult;
+
 typedef struct Uplink_UploadResult {
     Uplink_Upload *upload;
     Uplink_Error *error;
 } Uplink_UploadResult;
 
+typedef struct Uplink_ResumeUploadResult {
+    Uplink_Upload *upload;
+    // offset is the position in the data from which the upload continues.
+    int64_t offset;
+    Uplink_Error *error;
+} Uplink_ResumeUploadResult;
+
+enum {
+    // the metadata was changed without transferring the object data.
+    // It is reserved, currently every update rewrites the object.
+    UPLINK_METADATA_UPDATE_IN_PLACE = 0x01,
+    // the object data was streamed into a new object with the changed metadata.
+    UPLINK_METADATA_UPDATE_REWRITE = 0x02
+};
+
+typedef struct Uplink_UpdateObjectMetadataResult {
+    Uplink_Object *object;
+    // method is one of UPLINK_METADATA_UPDATE_*.
+    uint32_t method;
+    Uplink_Error *error;
+} Uplink_UpdateObjectMetadataResult;
+
+typedef struct Uplink_DeleteFailure {
+    const char *key;
+    Uplink_Error *error;
+} Uplink_DeleteFailure;
+
+typedef struct Uplink_DeletePrefixResult {
+    int64_t deleted;
+    Uplink_DeleteFailure *failures;
+    size_t failures_count;
+    Uplink_Error *error;
+} Uplink_DeletePrefixResult;
+
+typedef struct Uplink_DeleteBucketResult {
+    Uplink_Bucket *bucket;
+    int64_t deleted_objects;
+    Uplink_DeleteFailure *failures;
+    size_t failures_count;
+    Uplink_Error *error;
+} Uplink_DeleteBucketResult;
+
+typedef struct Uplink_DeleteObjectsEntry {
+    const char *key;
+    // error is NULL when the key was deleted.
+    Uplink_Error *error;
+} Uplink_DeleteObjectsEntry;
+
+typedef struct Uplink_DeleteObjectsResult {
+    // results are in the same order as the keys.
+    Uplink_DeleteObjectsEntry *results;
+    size_t count;
+    Uplink_Error *error;
+} Uplink_DeleteObjectsResult;
+
+typedef struct Uplink_StatObjectsResult {
+    // results are in the same order as the keys.
+    Uplink_ObjectResult *results;
+    size_t count;
+    Uplink_Error *error;
+} Uplink_StatObjectsResult;
+
+typedef struct Uplink_PrefixSummary {
+    const char *prefix;
+    int64_t objects;
+    int64_t bytes;
+    // oldest_created and newest_created are unix time in seconds, 0 when there are no objects.
+    int64_t oldest_created;
+    int64_t newest_created;
+} Uplink_PrefixSummary;
+
+typedef struct Uplink_SummarizePrefixResult {
+    Uplink_PrefixSummary summary;
+    // children are the summaries of nested prefixes ordered by prefix, in a single allocation.
+    Uplink_PrefixSummary *children;
+    size_t children_count;
+    Uplink_Error *error;
+} Uplink_SummarizePrefixResult;
+
+typedef struct Uplink_MovePrefixResult {
+    // moved is the number of objects moved before an error.
+    int64_t moved;
+    Uplink_Error *error;
+} Uplink_MovePrefixResult;
+
 typedef struct Uplink_DownloadResult {
     Uplink_Download *download;
     Uplink_Error *error;
 } Uplink_DownloadResult;
 
+typedef struct Uplink_ObjectReaderResult {
+    Uplink_ObjectReader *reader;
+    Uplink_Error *error;
+} Uplink_ObjectReaderResult;
+
 typedef struct Uplink_WriteResult {
     size_t bytes_written;
     Uplink_Error *error;
@@ -182,6 +524,11 @@ typedef struct Uplink_ReadResult {
     Uplink_Error *error;
 } Uplink_ReadResult;
 
+typedef struct Uplink_SeekResult {
+    int64_t position;
+    Uplink_Error *error;
+} Uplink_SeekResult;
+
 typedef struct Uplink_StringResult {
     const char *string;
     Uplink_Error *error;
@@ -190,4 +537,21 @@ typedef struct Uplink_StringResult {
 typedef struct Uplink_EncryptionKeyResult {
     Uplink_EncryptionKey *encryption_key;
     Uplink_Error *error;
-} Uplink_EncryptionKeyResult;
\ No newline at end of file
+} Uplink_EncryptionKeyResult;
+
+typedef struct Uplink_CompletionQueueResult {
+    Uplink_CompletionQueue *queue;
+    Uplink_Error *error;
+} Uplink_CompletionQueueResult;
+
+// Callbacks of the asynchronous functions are invoked from a thread created by the
+// library, never from the thread that started the operation. Callbacks that belong
+// to the same handle are invoked one at a time, in the order the operations were
+// started. The callback takes ownership of the result and must free it.
+typedef void (*Uplink_ReadCallback)(Uplink_ReadResult result, void *user_data);
+typedef void (*Uplink_WriteCallback)(Uplink_WriteResult result, void *user_data);
+typedef void (*Uplink_ErrorCallback)(Uplink_Error *error, void *user_data);
+
+// Listing callbacks return false to stop the listing early.
+typedef bool (*Uplink_ObjectCallback)(Uplink_Object *object, void *user_data);
+typedef bool (*Uplink_BucketCallback)(Uplink_Bucket *bucket, void *user_data);
@@ -6,6 +6,8 @@ package main
 // #include "uplink_definitions.h"
 import "C"
 import (
+	"context"
+	"io"
 	"reflect"
 	"time"
 	"unsafe"
@@ -16,12 +18,22 @@ import (
 // Upload is a partial upload to Storj Network.
 type Upload struct {
 	scope
-	upload *uplink.Upload
+	upload   objectUpload
+	async    asyncQueue
+	progress *progress
 }
 
 //export uplink_upload_object
 // uplink_upload_object starts an upload to the specified key.
 func uplink_upload_object(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_UploadOptions) C.Uplink_UploadResult { //nolint:golint
+	return uplink_upload_object_with_cancel(project, bucket_name, object_key, options, nil)
+}
+
+//export uplink_upload_object_with_cancel
+// uplink_upload_object_with_cancel starts an upload to the specified key.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_upload_object_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_UploadOptions, token *C.Uplink_CancelToken) C.Uplink_UploadResult { //nolint:golint
 	if project == nil {
 		return C.Uplink_UploadResult{
 			error: mallocError(ErrNull.New("project")),
@@ -44,24 +56,82 @@ func uplink_upload_object(project *C.Uplink_Project, bucket_name, object_key *C.
 			error: mallocError(ErrInvalidHandle.New("project")),
 		}
 	}
-	scope := proj.scope.child()
-
-	opts := &uplink.UploadOptions{}
-	if options != nil {
-		if options.expires > 0 {
-			opts.Expires = time.Unix(int64(options.expires), 0)
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_UploadResult{
+			error: mallocError(err),
 		}
 	}
 
-	upload, err := proj.UploadObject(scope.ctx, C.GoString(bucket_name), C.GoString(object_key), opts)
+	upload, err := startUpload(scope.ctx, proj, C.GoString(bucket_name), C.GoString(object_key), options)
 	if err != nil {
+		scope.cancel()
 		return C.Uplink_UploadResult{
 			error: mallocError(err),
 		}
 	}
 
 	return C.Uplink_UploadResult{
-		upload: (*C.Uplink_Upload)(mallocHandle(universe.Add(&Upload{scope, upload}))),
+		upload: (*C.Uplink_Upload)(mallocHandle(universe.Add(&Upload{scope: scope, upload: upload, progress: uploadProgress(options)}))),
+	}
+}
+
+//export uplink_resume_upload
+// uplink_resume_upload continues an interrupted resumable upload to the specified key.
+//
+// The returned offset is the position in the original data from which the writes
+// must continue. Options are only used for concurrency, progress reporting, the rate
+// limit and additional checksums, the remaining settings are restored from the
+// interrupted upload. Compression cannot be enabled for resumed uploads.
+func uplink_resume_upload(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_UploadOptions) C.Uplink_ResumeUploadResult { //nolint:golint
+	return uplink_resume_upload_with_cancel(project, bucket_name, object_key, options, nil)
+}
+
+//export uplink_resume_upload_with_cancel
+// uplink_resume_upload_with_cancel continues an interrupted resumable upload to the specified key.
+//
+// The call is canceled when token is canceled or its deadline passes.
+func uplink_resume_upload_with_cancel(project *C.Uplink_Project, bucket_name, object_key *C.char, options *C.Uplink_UploadOptions, token *C.Uplink_CancelToken) C.Uplink_ResumeUploadResult { //nolint:golint
+	if project == nil {
+		return C.Uplink_ResumeUploadResult{
+			error: mallocError(ErrNull.New("project")),
+		}
+	}
+	if bucket_name == nil {
+		return C.Uplink_ResumeUploadResult{
+			error: mallocError(ErrNull.New("bucket_name")),
+		}
+	}
+	if object_key == nil {
+		return C.Uplink_ResumeUploadResult{
+			error: mallocError(ErrNull.New("object_key")),
+		}
+	}
+
+	proj, ok := universe.Get(project._handle).(*Project)
+	if !ok {
+		return C.Uplink_ResumeUploadResult{
+			error: mallocError(ErrInvalidHandle.New("project")),
+		}
+	}
+	scope, err := proj.scope.childWithCancel(token)
+	if err != nil {
+		return C.Uplink_ResumeUploadResult{
+			error: mallocError(err),
+		}
+	}
+
+	upload, offset, err := continueUpload(scope.ctx, proj, C.GoString(bucket_name), C.GoString(object_key), options)
+	if err != nil {
+		scope.cancel()
+		return C.Uplink_ResumeUploadResult{
+			error: mallocError(err),
+		}
+	}
+
+	return C.Uplink_ResumeUploadResult{
+		upload: (*C.Uplink_Upload)(mallocHandle(universe.Add(&Upload{scope: scope, upload: upload, progress: uploadProgress(options)}))),
+		offset: C.int64_t(offset),
 	}
 }
 
@@ -92,6 +162,7 @@ func uplink_upload_write(upload *C.Uplink_Upload, bytes unsafe.Pointer, length C
 	}
 
 	n, err := up.upload.Write(buf)
+	up.progress.Add(n)
 	return C.Uplink_WriteResult{
 		bytes_written: C.size_t(n),
 		error:         mallocError(err),
@@ -107,9 +178,183 @@ func uplink_upload_commit(upload *C.Uplink_Upload) *C.Uplink_Error {
 	}
 
 	err := up.upload.Commit()
+	if err == nil {
+		up.progress.Finish()
+	}
 	return mallocError(err)
 }
 
+//export uplink_upload_write_async
+// uplink_upload_write_async starts uploading length bytes from bytes to the object's data stream.
+//
+// The callback is invoked with the write result once the write finishes and bytes must stay
+// valid until then. When the write cannot be started, an error is returned and the
+// callback is not invoked.
+func uplink_upload_write_async(upload *C.Uplink_Upload, bytes unsafe.Pointer, length C.size_t, callback C.Uplink_WriteCallback, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if upload == nil {
+		return mallocError(ErrNull.New("upload"))
+	}
+	if callback == nil {
+		return mallocError(ErrNull.New("callback"))
+	}
+
+	up, ok := universe.Get(upload._handle).(*Upload)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("upload"))
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return mallocError(ErrInvalidArg.New("length too large"))
+	}
+
+	up.writeAsync(bytes, ilength, func(n int, err error) {
+		callWriteCallback(callback, C.Uplink_WriteResult{
+			bytes_written: C.size_t(n),
+			error:         mallocError(err),
+		}, user_data)
+	})
+
+	return nil
+}
+
+//export uplink_upload_write_queued
+// uplink_upload_write_queued starts uploading length bytes from bytes to the object's data stream.
+//
+// Once the write finishes a completion with user_data is added to the queue and bytes must
+// stay valid until then. When the write cannot be started, an error is returned and no
+// completion is added.
+func uplink_upload_write_queued(upload *C.Uplink_Upload, bytes unsafe.Pointer, length C.size_t, queue *C.Uplink_CompletionQueue, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if upload == nil {
+		return mallocError(ErrNull.New("upload"))
+	}
+	if queue == nil {
+		return mallocError(ErrNull.New("queue"))
+	}
+
+	up, ok := universe.Get(upload._handle).(*Upload)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("upload"))
+	}
+	completions, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("queue"))
+	}
+
+	ilength, ok := safeConvertToInt(length)
+	if !ok {
+		return mallocError(ErrInvalidArg.New("length too large"))
+	}
+
+	up.writeAsync(bytes, ilength, func(n int, err error) {
+		completions.push(completion{
+			kind:     C.UPLINK_COMPLETION_WRITE,
+			userData: user_data,
+			bytes:    n,
+			err:      err,
+		})
+	})
+
+	return nil
+}
+
+//export uplink_upload_commit_async
+// uplink_upload_commit_async starts committing the uploaded data.
+//
+// The callback is invoked once all previously started writes have finished and
+// the commit completes. When the commit cannot be started, an error is returned and
+// the callback is not invoked.
+func uplink_upload_commit_async(upload *C.Uplink_Upload, callback C.Uplink_ErrorCallback, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if upload == nil {
+		return mallocError(ErrNull.New("upload"))
+	}
+	if callback == nil {
+		return mallocError(ErrNull.New("callback"))
+	}
+
+	up, ok := universe.Get(upload._handle).(*Upload)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("upload"))
+	}
+
+	up.commitAsync(func(err error) {
+		callErrorCallback(callback, err, user_data)
+	})
+
+	return nil
+}
+
+//export uplink_upload_commit_queued
+// uplink_upload_commit_queued starts committing the uploaded data.
+//
+// Once all previously started writes have finished and the commit completes, a completion
+// with user_data is added to the queue. When the commit cannot be started, an error is
+// returned and no completion is added.
+func uplink_upload_commit_queued(upload *C.Uplink_Upload, queue *C.Uplink_CompletionQueue, user_data unsafe.Pointer) *C.Uplink_Error { //nolint:golint
+	if upload == nil {
+		return mallocError(ErrNull.New("upload"))
+	}
+	if queue == nil {
+		return mallocError(ErrNull.New("queue"))
+	}
+
+	up, ok := universe.Get(upload._handle).(*Upload)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("upload"))
+	}
+	completions, ok := universe.Get(queue._handle).(*CompletionQueue)
+	if !ok {
+		return mallocError(ErrInvalidHandle.New("queue"))
+	}
+
+	up.commitAsync(func(err error) {
+		completions.push(completion{
+			kind:     C.UPLINK_COMPLETION_COMMIT,
+			userData: user_data,
+			err:      err,
+		})
+	})
+
+	return nil
+}
+
+// writeAsync writes bytes in the background and calls done with the result.
+func (up *Upload) writeAsync(bytes unsafe.Pointer, length int, done func(n int, err error)) {
+	up.async.Go(func() {
+		if err := up.scope.ctx.Err(); err != nil {
+			done(0, err)
+			return
+		}
+
+		var buf []byte
+		*(*reflect.SliceHeader)(unsafe.Pointer(&buf)) = reflect.SliceHeader{
+			Data: uintptr(bytes),
+			Len:  length,
+			Cap:  length,
+		}
+
+		n, err := up.upload.Write(buf)
+		up.progress.Add(n)
+		done(n, err)
+	})
+}
+
+// commitAsync commits the upload in the background and calls done with the result.
+func (up *Upload) commitAsync(done func(err error)) {
+	up.async.Go(func() {
+		if err := up.scope.ctx.Err(); err != nil {
+			done(err)
+			return
+		}
+
+		err := up.upload.Commit()
+		if err == nil {
+			up.progress.Finish()
+		}
+		done(err)
+	})
+}
+
 //export uplink_upload_abort
 // uplink_upload_abort aborts an upload.
 func uplink_upload_abort(upload *C.Uplink_Upload) *C.Uplink_Error {
@@ -158,6 +403,13 @@ func uplink_free_write_result(result C.Uplink_WriteResult) {
 	uplink_free_error(result.error)
 }
 
+//export uplink_free_resume_upload_result
+// uplink_free_resume_upload_result closes the upload and frees any associated resources.
+func uplink_free_resume_upload_result(result C.Uplink_ResumeUploadResult) {
+	uplink_free_error(result.error)
+	freeUpload(result.upload)
+}
+
 //export uplink_free_upload_result
 // free_upload_result closes the upload and frees any associated resources.
 func uplink_free_upload_result(result C.Uplink_UploadResult) {
@@ -165,6 +417,107 @@ func uplink_free_upload_result(result C.Uplink_UploadResult) {
 	freeUpload(result.upload)
 }
 
+// objectUpload is the upload of an object.
+type objectUpload interface {
+	io.Writer
+	Commit() error
+	Abort() error
+	Info() *uplink.Object
+	SetCustomMetadata(ctx context.Context, custom uplink.CustomMetadata) error
+}
+
+// startUpload starts an upload to the specified key. When options enable
+// concurrency or resuming, the data is uploaded in parts described by a manifest.
+// The data is compressed when enabled and checksums of the uncompressed data
+// are computed and stored on commit.
+func startUpload(ctx context.Context, proj *Project, bucket, key string, options *C.Uplink_UploadOptions) (objectUpload, error) {
+	opts := uploadOptions(options)
+
+	if options != nil && bool(options.resumable) && options.compression != C.UPLINK_COMPRESSION_NONE {
+		return nil, ErrInvalidArg.New("resumable uploads cannot be compressed")
+	}
+
+	var upload objectUpload
+	var err error
+	if policy := uploadParallelPolicy(options); options != nil && bool(options.resumable) {
+		var path string
+		path, err = proj.journalPath(bucket, key)
+		if err == nil {
+			upload, err = startResumableUpload(ctx, proj.Project, path, bucket, key, opts, policy, uint32(options.checksums))
+		}
+	} else if policy.enabled() {
+		upload, err = newChunkedUpload(ctx, proj.Project, bucket, key, opts, policy)
+	} else {
+		var plain *uplink.Upload
+		plain, err = proj.UploadObject(ctx, bucket, key, opts)
+		if err == nil {
+			upload = &replacingUpload{Upload: plain, ctx: ctx, project: proj.Project, bucket: bucket, key: key}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	upload = throttleUpload(ctx, upload, proj.uploadLimiter(options))
+	if options != nil {
+		compressed, err := newCompressedUpload(ctx, upload, options.compression)
+		if err != nil {
+			abandonUpload(upload)
+			return nil, err
+		}
+		upload = newChecksumUpload(ctx, compressed, options.checksums)
+	}
+	return upload, nil
+}
+
+// continueUpload resumes the interrupted resumable upload to the specified key and
+// returns the offset from which the data must be written. The checksums enabled for
+// the interrupted upload or in options are computed, for which the already uploaded
+// parts are downloaded again.
+func continueUpload(ctx context.Context, proj *Project, bucket, key string, options *C.Uplink_UploadOptions) (objectUpload, int64, error) {
+	if options != nil && options.compression != C.UPLINK_COMPRESSION_NONE {
+		return nil, 0, ErrInvalidArg.New("resumable uploads cannot be compressed")
+	}
+
+	path, err := proj.journalPath(bucket, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	resumed, offset, err := resumeUpload(ctx, proj.Project, path, bucket, key, uploadParallelPolicy(options))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	flags := C.uint32_t(resumed.journal.state.Checksums)
+	if options != nil {
+		flags |= options.checksums
+	}
+	upload, err := newResumedChecksumUpload(ctx, throttleUpload(ctx, resumed, proj.uploadLimiter(options)), resumed, flags)
+	if err != nil {
+		resumed.release()
+		return nil, 0, err
+	}
+	return upload, offset, nil
+}
+
+// uploadLimiter returns the rate limiter for an upload with options.
+func (proj *Project) uploadLimiter(options *C.Uplink_UploadOptions) *rateLimiter {
+	if options == nil {
+		return proj.bandwidth.upload
+	}
+	return transferLimiter(proj.bandwidth.upload, options.bytes_per_second)
+}
+
+func uploadOptions(options *C.Uplink_UploadOptions) *uplink.UploadOptions {
+	opts := &uplink.UploadOptions{}
+	if options != nil {
+		if options.expires > 0 {
+			opts.Expires = time.Unix(int64(options.expires), 0)
+		}
+	}
+	return opts
+}
+
 func freeUpload(upload *C.Uplink_Upload) {
 	if upload == nil {
 		return
@@ -175,5 +528,34 @@ func freeUpload(upload *C.Uplink_Upload) {
 	up, ok := universe.Get(upload._handle).(*Upload)
 	if ok {
 		up.cancel()
+		if chunked, ok := unwrapUpload(up.upload).(*chunkedUpload); ok {
+			chunked.release()
+		}
+	}
+}
+
+// abandonUpload stops an upload that cannot be finished,
+// the committed parts of a resumable upload are kept.
+func abandonUpload(upload objectUpload) {
+	if chunked, ok := unwrapUpload(upload).(*chunkedUpload); ok {
+		chunked.release()
+		return
+	}
+	_ = upload.Abort()
+}
+
+// unwrapUpload returns the upload without the checksum computation, compression and throttling.
+func unwrapUpload(upload objectUpload) objectUpload {
+	for {
+		switch wrapped := upload.(type) {
+		case *checksumUpload:
+			upload = wrapped.objectUpload
+		case *throttledUpload:
+			upload = wrapped.objectUpload
+		case *compressedUpload:
+			upload = wrapped.objectUpload
+		default:
+			return upload
+		}
 	}
 }